@@ -0,0 +1,221 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"strings"
+
+	aligner "github.com/szenzaro/iliad-aligner/aligner"
+)
+
+// alignFlags holds the flags of the commands aligning the words DB with a saved model
+type alignFlags struct {
+	paths   dataPaths
+	model   *string
+	chant   *string
+	workers *int
+	aligner *string
+}
+
+func addAlignFlags(fs *flag.FlagSet) alignFlags {
+	f := addModelFlags(fs, "data/G44_HOM.xlsx,data/G44_PARA.xlsx")
+	f.chant = fs.String("chant", "", "align only the verses of the given chant")
+	return f
+}
+
+// addModelFlags adds the flags needed to align verses with a saved model
+func addModelFlags(fs *flag.FlagSet, words string) alignFlags {
+	f := alignFlags{
+		paths: dataPaths{
+			words: fs.String("w", words, "comma separated paths of the xlsx files containing the words to align"),
+		},
+		model:   fs.String("m", "out/model.json", "path to the model file produced by the train command"),
+		workers: fs.Int("workers", 0, "number of verses aligned in parallel, 0 uses all the CPUs"),
+		aligner: fs.String("aligner", "greek", "aligner used to compute the alignments: greek, hungarian, monotonic, itg or beam[:width]"),
+	}
+	addResourceFlags(fs, &f.paths)
+	return f
+}
+
+// alignAll aligns every verse of the words DB, or of the chant given by the
+// flags, returning the alignments by problem ID
+func (f alignFlags) alignAll() map[string]*aligner.Alignment {
+	return f.alignVerses(f.inChant)
+}
+
+// inChant keeps the verses of the chant given by the flags, or all of them
+func (f alignFlags) inChant(id string) bool {
+	return *f.chant == "" || strings.HasPrefix(id, *f.chant+".")
+}
+
+// loadModel loads the model, the words DB and the additional data needed by the features
+func (f alignFlags) loadModel() (*aligner.Model, *aligner.Context, aligner.DB) {
+	fmt.Println("Loading words database")
+	wordsDB, err := aligner.LoadDB(strings.Split(*f.paths.words, ","))
+	if err != nil {
+		log.Fatalln(err)
+	}
+	model, ctx := f.loadModelFor(wordsDB)
+	return model, ctx, wordsDB
+}
+
+// loadModelFor loads the model and the additional data needed by the features
+// of an already loaded words DB
+func (f alignFlags) loadModelFor(wordsDB aligner.DB) (*aligner.Model, *aligner.Context) {
+	if *f.workers < 0 {
+		log.Fatalln("-workers must not be negative, got ", *f.workers)
+	}
+	model, err := aligner.LoadModel(*f.model)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	ctx := loadAdditionalData(f.paths)
+	ctx.LoadVerses(wordsDB)
+	if model.Association != nil {
+		ctx.Set("LemmaAssociation", model.Association)
+	}
+	if err := aligner.CheckResources(model.Features, ctx); err != nil {
+		log.Fatalln(err)
+	}
+	return model, ctx
+}
+
+// alignVerses aligns the verses of the words DB whose ID is kept, returning
+// the alignments by problem ID
+func (f alignFlags) alignVerses(keep func(id string) bool) map[string]*aligner.Alignment {
+	model, ctx, wordsDB := f.loadModel()
+	return f.alignWith(model, ctx, wordsDB, keep)
+}
+
+// alignWith aligns the kept verses like alignVerses with an already loaded model
+func (f alignFlags) alignWith(model *aligner.Model, ctx *aligner.Context, wordsDB aligner.DB, keep func(id string) bool) map[string]*aligner.Alignment {
+	alignments := map[string]*aligner.Alignment{}
+	for id, as := range f.alignNBest(model, ctx, wordsDB, keep, 1) {
+		alignments[id] = as[0].Alignment
+	}
+	return alignments
+}
+
+// alignNBest computes the n best alignments of the kept verses, returning them
+// by problem ID
+func (f alignFlags) alignNBest(model *aligner.Model, ctx *aligner.Context, wordsDB aligner.DB, keep func(id string) bool, n int) map[string][]aligner.ScoredAlignment {
+	ff, w, err := model.Resolve()
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	problems := aligner.ProblemsFromDB(wordsDB)
+	ids := []string{}
+	for id := range problems {
+		if keep(id) {
+			ids = append(ids, id)
+		}
+	}
+	aligner.SortProblemIDs(ids)
+
+	ar, err := newAligner(*f.aligner)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	results := make([][]aligner.ScoredAlignment, len(ids))
+	forEachParallel(len(ids), *f.workers, ctx, func(i int, c *aligner.Context) {
+		fmt.Println(ids[i], " ", i+1, "/", len(ids))
+		c.ResetCache()
+		p := problems[ids[i]]
+		as, err := aligner.NewFromWordBags(p.From, p.To).AlignNBest(ar, ff, w, model.Params.SubseqLen, n, c)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		results[i] = as
+	})
+
+	alignments := map[string][]aligner.ScoredAlignment{}
+	for i, id := range ids {
+		alignments[id] = results[i]
+	}
+	return alignments
+}
+
+func alignCmd(args []string) {
+	fs := flag.NewFlagSet("align", flag.ExitOnError)
+	af := addAlignFlags(fs)
+	outPath := fs.String("o", "out/alignment.json", "path to the output file")
+	format := fs.String("format", "json", "output format: json or tmx")
+	nbest := fs.Int("nbest", 1, "number of distinct alignments written for each verse, with their scores")
+	fs.Parse(args)
+
+	if *nbest > 1 {
+		if *format != "json" {
+			log.Fatalln("-nbest requires the json format")
+		}
+		model, ctx, wordsDB := af.loadModel()
+		if err := writeNBest(*outPath, af.alignNBest(model, ctx, wordsDB, af.inChant, *nbest)); err != nil {
+			log.Fatalln(err)
+		}
+		fmt.Println("Alignments saved to ", *outPath)
+		return
+	}
+
+	var write func(string, map[string]*aligner.Alignment) error
+	switch *format {
+	case "json":
+		write = writeAlignments
+	case "tmx":
+		write = writeTMX
+	default:
+		log.Fatalln("unknown output format ", *format)
+	}
+
+	alignments := af.alignAll()
+	if err := write(*outPath, alignments); err != nil {
+		log.Fatalln(err)
+	}
+	fmt.Println("Alignments saved to ", *outPath)
+}
+
+// writeAlignments saves the edits of each alignment as JSON, keyed by problem ID
+func writeAlignments(path string, alignments map[string]*aligner.Alignment) error {
+	data := map[string][]aligner.JSONEdit{}
+	for id, a := range alignments {
+		data[id] = jsonEdits(a)
+	}
+	return writeJSON(path, data)
+}
+
+// rankedAlignment is an alignment written by align -nbest
+type rankedAlignment struct {
+	Score  float64            `json:"score"`
+	Margin float64            `json:"margin"` // score of the first alignment minus this score
+	Edits  []aligner.JSONEdit `json:"edits"`
+}
+
+// writeNBest saves the alternative alignments of each verse as JSON, keyed by
+// problem ID, starting with the best one
+func writeNBest(path string, alignments map[string][]aligner.ScoredAlignment) error {
+	data := map[string][]rankedAlignment{}
+	for id, as := range alignments {
+		for _, a := range as {
+			data[id] = append(data[id], rankedAlignment{Score: a.Score, Margin: a.Margin, Edits: jsonEdits(a.Alignment)})
+		}
+	}
+	return writeJSON(path, data)
+}
+
+func jsonEdits(a *aligner.Alignment) []aligner.JSONEdit {
+	edits := []aligner.JSONEdit{}
+	for _, e := range a.Edits() {
+		edits = append(edits, e.(aligner.JSONEditer).ToJSONEdit())
+	}
+	return edits
+}
+
+func writeJSON(path string, v interface{}) error {
+	d, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, d, 0644)
+}
@@ -56,6 +56,42 @@ func (e *Sub) ToJSONEdit() JSONEdit {
 	return JSONEdit{Type: "sub", Source: ss, Target: tt}
 }
 
+// ToEdit creates the edit described by e, taking its words from the DB
+func (e JSONEdit) ToEdit(db DB) (Edit, error) {
+	words := func(ids []string) ([]Word, error) {
+		ws := []Word{}
+		for _, id := range ids {
+			w, ok := db[id]
+			if !ok {
+				return nil, fmt.Errorf("unknown word %s", id)
+			}
+			ws = append(ws, w)
+		}
+		return ws, nil
+	}
+	from, err := words(e.Source)
+	if err != nil {
+		return nil, err
+	}
+	to, err := words(e.Target)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case e.Type == "ins" && len(from) == 0 && len(to) == 1:
+		return &Ins{W: to[0]}, nil
+	case e.Type == "del" && len(from) == 1 && len(to) == 0:
+		return &Del{W: from[0]}, nil
+	case e.Type == "eq" && len(from) == 1 && len(to) == 1:
+		return &Eq{From: from[0], To: to[0]}, nil
+	case e.Type == "sub" && len(from) > 0 && len(to) > 0:
+		return &Sub{From: from, To: to}, nil
+	}
+	return nil, fmt.Errorf("invalid %s edit with %d source and %d target words", e.Type, len(from), len(to))
+}
+
+// ToJSONEdits explodes the edits of the alignment by source and by target word ID
 func (a *Alignment) ToJSONEdits() (map[string]JSONEdit, map[string]JSONEdit) {
 	le := map[string]JSONEdit{}
 	re := map[string]JSONEdit{}
@@ -77,16 +113,66 @@ func (a *Alignment) ToJSONEdits() (map[string]JSONEdit, map[string]JSONEdit) {
 	return le, re
 }
 
-func MergeAlignments(a, b *Alignment) *Alignment {
-	edits := make([]Edit, len(a.editMap)+len(b.editMap))
-	i := 0
-	for _, v := range a.editMap {
-		edits[i] = v
-		i++
+// MergeAlignments creates an alignment containing the edits of all the given
+// alignments, in time linear in their number of edits
+func MergeAlignments(as ...*Alignment) *Alignment {
+	res := NewFromEdits()
+	for _, a := range as {
+		for _, v := range a.editMap {
+			res.Add(v)
+		}
 	}
-	for _, v := range b.editMap {
-		edits[i] = v
-		i++
+	return res
+}
+
+// ViewerFormatVersion is the version of the JSON schema produced by NewViewerAlignment
+const ViewerFormatVersion = 1
+
+// ViewerWord describes a word referenced by the edits of a ViewerAlignment
+type ViewerWord struct {
+	ID     string `json:"id"`
+	Source string `json:"source"`
+	Text   string `json:"text"`
+	Lemma  string `json:"lemma"`
+	Chant  string `json:"chant"`
+	Verse  string `json:"verse"`
+}
+
+// ViewerAlignment is the JSON document consumed by the Iliadoscope viewer.
+// Left maps each HOM word ID to its edit, where source lists the other HOM words
+// of the same edit and target the PARA words. Right maps each PARA word ID to its
+// edit, where source lists the other PARA words and target the HOM words.
+type ViewerAlignment struct {
+	Version int                   `json:"version"`
+	Verses  []string              `json:"verses"`
+	Words   map[string]ViewerWord `json:"words"`
+	Left    map[string]JSONEdit   `json:"left"`
+	Right   map[string]JSONEdit   `json:"right"`
+}
+
+// NewViewerAlignment creates the viewer document of the alignment
+func NewViewerAlignment(a *Alignment) ViewerAlignment {
+	le, re := a.ToJSONEdits()
+	va := ViewerAlignment{
+		Version: ViewerFormatVersion,
+		Verses:  []string{},
+		Words:   map[string]ViewerWord{},
+		Left:    le,
+		Right:   re,
+	}
+	verses := map[string]bool{}
+	for _, e := range a.editMap {
+		from, to := getWords(e)
+		for _, ws := range [][]Word{from, to} {
+			for _, w := range ws {
+				va.Words[w.ID] = ViewerWord{ID: w.ID, Source: w.Source, Text: w.Text, Lemma: w.Lemma, Chant: w.Chant, Verse: w.Verse}
+				if id := w.getProblemID(); !verses[id] {
+					verses[id] = true
+					va.Verses = append(va.Verses, id)
+				}
+			}
+		}
 	}
-	return NewFromEdits(edits...)
+	SortProblemIDs(va.Verses)
+	return va
 }
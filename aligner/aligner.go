@@ -23,14 +23,10 @@ import (
 	"golang.org/x/text/unicode/norm"
 )
 
-// AdditionalData store information useful when computing features
-var AdditionalData map[string]interface{}
-var scoreCache map[string]map[Edit]float64
-var scholiePrefixCache map[string][]string
-
 // Word contains the information about words
 type Word struct {
 	ID     string
+	Form   string // surface form, with case and punctuation
 	Text   string
 	Lemma  string
 	Tag    string
@@ -42,10 +38,9 @@ type Word struct {
 // DB is the database of words
 type DB = map[string]Word
 
-// ResetCache clear the cache
+// ResetCache clear the cache of the DefaultContext
 func ResetCache() {
-	scoreCache = map[string]map[Edit]float64{}
-	scholiePrefixCache = map[string][]string{}
+	DefaultContext.ResetCache()
 }
 
 // LoadDB retrieves all the words from the parameter paths
@@ -69,6 +64,7 @@ func LoadDB(paths []string) (DB, error) {
 					ID:     GetWordID(row.Cells[2].Value, row.Cells[0].Value), // Source.ID // TODO check id bis
 					Verse:  row.Cells[10].Value,
 					Chant:  row.Cells[3].Value,
+					Form:   strings.TrimSpace(row.Cells[15].Value),
 					Text:   row.Cells[19].Value, // Normalized text
 					Lemma:  row.Cells[20].Value,
 					Tag:    row.Cells[21].Value,
@@ -99,10 +95,10 @@ func (w *Word) getProblemID() string {
 }
 
 // Feature represents a computable feature for the alignment
-type Feature func(Edit, map[string]interface{}) float64 // func(sw, tw []word) float64
+type Feature func(Edit, *Context) float64 // func(sw, tw []word) float64
 
 // EditType compute the score using the edit type
-func EditType(e Edit, data map[string]interface{}) float64 {
+func EditType(e Edit, ctx *Context) float64 {
 	switch e.(type) {
 	case *Ins:
 		return 1.0
@@ -118,14 +114,14 @@ func EditType(e Edit, data map[string]interface{}) float64 {
 }
 
 // MaxDistance combines the distances of the other features
-func MaxDistance(e Edit, data map[string]interface{}) float64 {
+func MaxDistance(e Edit, ctx *Context) float64 {
 	return multiMax(
-		LexicalSimilarity(e, data),
-		LemmaDistance(e, data),
-		TagDistance(e, data),
-		VocDistance(e, data),
-		ScholieDistance(e, data),
-		EqEquivTermDistance(e, data),
+		LexicalSimilarity(e, ctx),
+		LemmaDistance(e, ctx),
+		TagDistance(e, ctx),
+		VocDistance(e, ctx),
+		ScholieDistance(e, ctx),
+		EqEquivTermDistance(e, ctx),
 	)
 }
 
@@ -147,8 +143,14 @@ func getWords(e Edit) ([]Word, []Word) {
 	return from, to
 }
 
-// LoadScholie gets the data from the available scholies
+// LoadScholie gets the data from the available scholies and stores it in the DefaultContext
 func LoadScholie(path string) (*trie.Trie, error) {
+	defer syncAdditionalData()
+	return DefaultContext.LoadScholie(path)
+}
+
+// LoadScholie gets the data from the available scholies and stores it in the context
+func (c *Context) LoadScholie(path string) (*trie.Trie, error) {
 	jsonFile, err := os.Open(path)
 	if err != nil {
 		return nil, err
@@ -170,10 +172,7 @@ func LoadScholie(path string) (*trie.Trie, error) {
 			sch.Add(k, v)
 		}
 	}
-	if AdditionalData == nil {
-		AdditionalData = map[string]interface{}{}
-	}
-	AdditionalData["ScholieDistance"] = sch
+	c.Set("ScholieDistance", sch)
 	return sch, nil
 }
 
@@ -186,21 +185,16 @@ func normalizeText(s string) string {
 	return conv
 }
 
-func getScholieEntries(entry string, sch map[string]interface{}) []string {
-	if scholiePrefixCache == nil {
-		scholiePrefixCache = map[string][]string{}
+func getScholieEntries(entry string, ctx *Context) []string {
+	if v, ok := ctx.cachedScholieEntries(entry); ok {
+		return v
 	}
 	entries := []string{}
-	scholie := sch["ScholieDistance"].(*trie.Trie)
-	scholieEntries := []string{}
-	if v, ok := scholiePrefixCache[entry]; ok {
-		scholieEntries = v
-	} else {
-		scholieEntries = scholie.PrefixSearch(entry)
-	}
+	scholie := ctx.scholieTrie()
+	scholieEntries := scholie.PrefixSearch(entry)
 
 	if entry == "" || len(scholieEntries) == 0 {
-		scholiePrefixCache[entry] = entries
+		ctx.cacheScholieEntries(entry, entries)
 		return entries
 	}
 
@@ -209,23 +203,21 @@ func getScholieEntries(entry string, sch map[string]interface{}) []string {
 			entries = append(entries, x.Meta().([]string)...)
 		}
 	}
-	scholiePrefixCache[entry] = entries
+	ctx.cacheScholieEntries(entry, entries)
 	return entries
 }
 
 // ScholieDistance computes the distance based onscholie
-func ScholieDistance(e Edit, sch map[string]interface{}) float64 {
-	initCache("ScholieDistance")
-
+func ScholieDistance(e Edit, ctx *Context) float64 {
 	switch e.(type) {
 	case *Ins:
-		scoreCache["ScholieDistance"][e] = 1.0
+		ctx.cacheScore("ScholieDistance", e, 1.0)
 		return 1.0
 	case *Del:
-		scoreCache["ScholieDistance"][e] = 1.0
+		ctx.cacheScore("ScholieDistance", e, 1.0)
 		return 1.0
 	}
-	// if s, ok := scoreCache["ScholieDistance"][e]; ok {
+	// if s, ok := ctx.cachedScore("ScholieDistance", e); ok {
 	// 	return s
 	// }
 
@@ -235,7 +227,7 @@ func ScholieDistance(e Edit, sch map[string]interface{}) float64 {
 
 	score := math.Inf(0)
 	targetText := normalizeText(target.Text)
-	for _, t := range getScholieEntries(entry, sch) {
+	for _, t := range getScholieEntries(entry, ctx) {
 		dist := levenshteinDistance(targetText, t) / multiMax(float64(len(t)), float64(len(targetText)))
 		if dist <= score {
 			score = dist
@@ -250,12 +242,18 @@ func ScholieDistance(e Edit, sch map[string]interface{}) float64 {
 	}
 
 	res := 1.0 - score
-	scoreCache["ScholieDistance"][e] = res
+	ctx.cacheScore("ScholieDistance", e, res)
 	return res
 }
 
-// LoadVoc loads the vocabulary data
+// LoadVoc loads the vocabulary data and stores it in the DefaultContext
 func LoadVoc(path string, dictName string) (map[string][]string, error) {
+	defer syncAdditionalData()
+	return DefaultContext.LoadVoc(path, dictName)
+}
+
+// LoadVoc loads the vocabulary data and stores it in the context with the given name
+func (c *Context) LoadVoc(path string, dictName string) (map[string][]string, error) {
 	xlFile, err := xlsx.OpenFile(path)
 	if err != nil {
 		return nil, err
@@ -277,10 +275,7 @@ func LoadVoc(path string, dictName string) (map[string][]string, error) {
 		}
 		voc[row.Cells[0].Value] = append(voc[row.Cells[0].Value], getMeanings(row.Cells[1].Value)...)
 	}
-	if AdditionalData == nil {
-		AdditionalData = map[string]interface{}{}
-	}
-	AdditionalData[dictName] = voc
+	c.Set(dictName, voc)
 	return voc, nil
 }
 
@@ -300,25 +295,14 @@ func hasSameMeaning(a, b []string) bool {
 	return false
 }
 
-func initCache(funcName string) {
-	if scoreCache == nil {
-		scoreCache = map[string]map[Edit]float64{}
-	}
-	if scoreCache[funcName] == nil {
-		scoreCache[funcName] = map[Edit]float64{}
-	}
-}
-
 // EqEquivTermDistance computes the distance based on greek equivalent terms
-func EqEquivTermDistance(e Edit, data map[string]interface{}) float64 {
+func EqEquivTermDistance(e Edit, ctx *Context) float64 {
 	vocName := "EquivTermDistance"
-	initCache(vocName)
-
-	if v, ok := scoreCache[vocName][e]; ok {
+	if v, ok := ctx.cachedScore(vocName, e); ok {
 		return v
 	}
 
-	voc := data[vocName].(map[string][]string)
+	voc := ctx.voc(vocName)
 	res := 0.0
 	switch e.(type) {
 	case *Eq:
@@ -335,20 +319,18 @@ func EqEquivTermDistance(e Edit, data map[string]interface{}) float64 {
 			}
 		}
 	}
-	scoreCache[vocName][e] = res
+	ctx.cacheScore(vocName, e, res)
 	return res
 }
 
 // VocDistance computes the distance based on vocabulary data
-func VocDistance(e Edit, data map[string]interface{}) float64 {
+func VocDistance(e Edit, ctx *Context) float64 {
 	vocName := "VocDistance"
-	initCache(vocName)
-
-	if v, ok := scoreCache[vocName][e]; ok {
+	if v, ok := ctx.cachedScore(vocName, e); ok {
 		return v
 	}
 
-	voc := data[vocName].(map[string][]string)
+	voc := ctx.voc(vocName)
 	res := 0.0
 	switch e.(type) {
 	case *Eq:
@@ -365,30 +347,30 @@ func VocDistance(e Edit, data map[string]interface{}) float64 {
 			}
 		}
 	}
-	scoreCache[vocName][e] = res
+	ctx.cacheScore(vocName, e, res)
 	return res
 }
 
 // LemmaDistance computes the distance based on the word lemma
-func LemmaDistance(e Edit, data map[string]interface{}) float64 {
-	return distanceOnField(e, data, "LemmaDistance", "Lemma")
+func LemmaDistance(e Edit, ctx *Context) float64 {
+	return distanceOnField(e, ctx, "LemmaDistance", "Lemma")
 }
 
 // TagDistance computes the distance based on the word tag
-func TagDistance(e Edit, data map[string]interface{}) float64 {
-	return distanceOnField(e, data, "TagDistance", "Tag")
+func TagDistance(e Edit, ctx *Context) float64 {
+	return distanceOnField(e, ctx, "TagDistance", "Tag")
 }
 
 // LexicalSimilarity computes the distance based on the word text
-func LexicalSimilarity(e Edit, data map[string]interface{}) float64 {
-	return distanceOnField(e, data, "LexicalSimilarity", "Text")
+func LexicalSimilarity(e Edit, ctx *Context) float64 {
+	return distanceOnField(e, ctx, "LexicalSimilarity", "Text")
 }
 
 // TextualDistance is the max between LE=exical and Lemma similarity
-func TextualDistance(e Edit, data map[string]interface{}) float64 {
+func TextualDistance(e Edit, ctx *Context) float64 {
 	return multiMax(
-		LexicalSimilarity(e, data),
-		LemmaDistance(e, data),
+		LexicalSimilarity(e, ctx),
+		LemmaDistance(e, ctx),
 	)
 }
 
@@ -401,9 +383,15 @@ func RemovePunctuation(s string) string {
 	return news
 }
 
-func distanceOnField(e Edit, data map[string]interface{}, funcName string, fieldName string) float64 {
-	initCache(funcName)
-	if v, ok := scoreCache[funcName][e]; ok {
+// SameText tells whether the words are aligned by an Eq rather than a Sub: their
+// texts are equal without punctuation. It is shared by the aligners and by the
+// readers of the gold standard, so that a saved Eq is read back as an Eq.
+func SameText(v, w Word) bool {
+	return RemovePunctuation(v.Text) == RemovePunctuation(w.Text)
+}
+
+func distanceOnField(e Edit, ctx *Context, funcName string, fieldName string) float64 {
+	if v, ok := ctx.cachedScore(funcName, e); ok {
 		return v
 	}
 
@@ -414,7 +402,7 @@ func distanceOnField(e Edit, data map[string]interface{}, funcName string, field
 
 	dist := 1 - levenshteinDistance(sourceValue, targetValue)/multiMax(float64(len(sourceValue)), float64(len(targetValue)))
 
-	scoreCache[funcName][e] = dist
+	ctx.cacheScore(funcName, e, dist)
 	return dist
 }
 
@@ -514,7 +502,7 @@ func levenshteinDistance(s, t string) float64 {
 // Edit is the edits interface
 type Edit interface {
 	fmt.Stringer
-	Score(fs []Feature, ws []float64, data map[string]interface{}) float64
+	Score(fs []Feature, ws []float64, ctx *Context) float64
 	GetProblemID() string
 }
 
@@ -541,11 +529,11 @@ func (e *Sub) GetProblemID() string {
 }
 
 // Score the edit
-func (e *Ins) Score(fs []Feature, ws []float64, data map[string]interface{}) float64 {
+func (e *Ins) Score(fs []Feature, ws []float64, ctx *Context) float64 {
 	score := 0.0
 	for i, f := range fs {
 		// score += w[i] * f([]word{}, []word{e.w})
-		score += ws[i] * f(e, data)
+		score += ws[i] * f(e, ctx)
 	}
 	return score
 }
@@ -556,10 +544,10 @@ type Del struct {
 }
 
 // Score the edit
-func (e *Del) Score(fs []Feature, ws []float64, data map[string]interface{}) float64 {
+func (e *Del) Score(fs []Feature, ws []float64, ctx *Context) float64 {
 	score := 0.0
 	for i, f := range fs {
-		score += ws[i] * f(e, data)
+		score += ws[i] * f(e, ctx)
 	}
 	return score
 }
@@ -571,10 +559,10 @@ type Eq struct {
 }
 
 // Score the edit
-func (e *Eq) Score(fs []Feature, ws []float64, data map[string]interface{}) float64 {
+func (e *Eq) Score(fs []Feature, ws []float64, ctx *Context) float64 {
 	score := 0.0
 	for i, f := range fs {
-		score += ws[i] * f(e, data)
+		score += ws[i] * f(e, ctx)
 	}
 	return score
 }
@@ -586,10 +574,10 @@ type Sub struct {
 }
 
 // Score the edit
-func (e *Sub) Score(fs []Feature, ws []float64, data map[string]interface{}) float64 {
+func (e *Sub) Score(fs []Feature, ws []float64, ctx *Context) float64 {
 	score := 0.0
 	for i, f := range fs {
-		score += ws[i] * f(e, data)
+		score += ws[i] * f(e, ctx)
 	}
 	return score
 }
@@ -671,8 +659,8 @@ func equalSub(s, t *Sub) bool {
 }
 
 // ScoreAccuracy checks the ratio of edits considering their score
-func ScoreAccuracy(a, b *Alignment, fs []Feature, w []float64, data map[string]interface{}) float64 {
-	sa, sb := a.Score(fs, w, data), b.Score(fs, w, data)
+func ScoreAccuracy(a, b *Alignment, fs []Feature, w []float64, ctx *Context) float64 {
+	sa, sb := a.Score(fs, w, ctx), b.Score(fs, w, ctx)
 	max := math.Max(sa, sb)
 	if max == 0.0 {
 		return 0.0
@@ -691,11 +679,17 @@ func (a *Alignment) EditsAccuracy(std *Alignment) float64 {
 	return float64(n) / float64(len(std.editMap))
 }
 
-// Score the aligment
-func (a *Alignment) Score(fs []Feature, ws []float64, data map[string]interface{}) float64 {
-	score := 0.0
+// Score the aligment. The edit scores are added in increasing order so that
+// the result does not depend on the map iteration order.
+func (a *Alignment) Score(fs []Feature, ws []float64, ctx *Context) float64 {
+	scores := make([]float64, 0, len(a.editMap))
 	for _, e := range a.editMap {
-		score += e.Score(fs, ws, data)
+		scores = append(scores, e.Score(fs, ws, ctx))
+	}
+	sort.Float64s(scores)
+	score := 0.0
+	for _, v := range scores {
+		score += v
 	}
 	return score
 }
@@ -707,13 +701,62 @@ func (a *Alignment) Add(es ...Edit) {
 	}
 }
 
+// Edits returns the edits of the alignment following the order of the words in the texts
+func (a *Alignment) Edits() []Edit {
+	edits := []Edit{}
+	inss := []Edit{}
+	for _, e := range a.editMap {
+		if _, ok := e.(*Ins); ok {
+			inss = append(inss, e)
+		} else {
+			edits = append(edits, e)
+		}
+	}
+	sort.SliceStable(edits, func(i, j int) bool {
+		fi, ti := getWords(edits[i])
+		fj, tj := getWords(edits[j])
+		if minWordIndex(fi) != minWordIndex(fj) {
+			return minWordIndex(fi) < minWordIndex(fj)
+		}
+		return minWordIndex(ti) < minWordIndex(tj)
+	})
+	sort.SliceStable(inss, func(i, j int) bool { return wordIndex(inss[i].(*Ins).W.ID) < wordIndex(inss[j].(*Ins).W.ID) })
+
+	res := make([]Edit, 0, len(a.editMap))
+	for _, e := range edits {
+		_, to := getWords(e)
+		for len(to) > 0 && len(inss) > 0 && wordIndex(inss[0].(*Ins).W.ID) < minWordIndex(to) {
+			res = append(res, inss[0])
+			inss = inss[1:]
+		}
+		res = append(res, e)
+	}
+	return append(res, inss...)
+}
+
+func minWordIndex(ws []Word) int {
+	min := math.MaxInt32
+	for _, w := range ws {
+		if i := wordIndex(w.ID); i < min {
+			min = i
+		}
+	}
+	return min
+}
+
 // Phi TODO
-func Phi(a *Alignment, fs []Feature, data map[string]interface{}) vectors.Vector {
+func Phi(a *Alignment, fs []Feature, ctx *Context) vectors.Vector {
 	v := make(vectors.Vector, len(fs))
 	for i, f := range fs {
-		featureValue := 0.0
+		// sum in a fixed order so that the result does not depend on the map iteration
+		values := make([]float64, 0, len(a.editMap))
 		for _, e := range a.editMap {
-			featureValue += f(e, data)
+			values = append(values, f(e, ctx))
+		}
+		sort.Float64s(values)
+		featureValue := 0.0
+		for _, x := range values {
+			featureValue += x
 		}
 		v[i] = featureValue
 	}
@@ -773,31 +816,19 @@ func (a *Alignment) String() string {
 }
 
 // Align computes the alignement using an aligner
-func (a *Alignment) Align(ar Aligner, fs []Feature, ws []float64, subseqLen int, data map[string]interface{}) (*Alignment, error) {
+func (a *Alignment) Align(ar Aligner, fs []Feature, ws []float64, subseqLen int, ctx *Context) (*Alignment, error) {
 	if len(fs) != len(ws) {
 		return nil, fmt.Errorf("features and weights len mismatch")
 	}
+	if s, ok := ar.(searcher); ok {
+		return s.search(a, fs, ws, subseqLen, ctx)
+	}
 	F := ar.next(a, subseqLen)
 	if len(F) == 0 {
 		return a, nil
 	}
-	maxScore := math.Inf(-1)
-	var maxAlign Alignment
-
-	// start := time.Now()
-	for _, a := range F { // go routine
-		score := a.Score(fs, ws, data)
-		if score > maxScore {
-			maxScore = score
-			maxAlign = a
-		}
-	}
-	// elapsed := time.Since(start)
-	// fmt.Println("scored all in ", elapsed)
-	// for _, v := range scored[:min(len(scored), 10)] {
-	// 	fmt.Println(v.a, " - ", v.v)
-	// }
-	return maxAlign.Align(ar, fs, ws, subseqLen, data)
+	maxAlign := F[bestCandidate(F, scoreCandidates(F, fs, ws, ctx))]
+	return maxAlign.Align(ar, fs, ws, subseqLen, ctx)
 }
 
 // WordsBag represents a set of words
@@ -862,8 +893,14 @@ func (a *Alignment) remove(es ...Edit) {
 	}
 }
 
-// LoadScholieDict gets the data from the available scholies
+// LoadScholieDict gets the data from the available scholies and stores it in the DefaultContext
 func LoadScholieDict(path string) (map[string][]string, error) {
+	defer syncAdditionalData()
+	return DefaultContext.LoadScholieDict(path)
+}
+
+// LoadScholieDict gets the data from the available scholies and stores it in the context
+func (c *Context) LoadScholieDict(path string) (map[string][]string, error) {
 	jsonFile, err := os.Open(path)
 	if err != nil {
 		return nil, err
@@ -884,15 +921,15 @@ func LoadScholieDict(path string) (map[string][]string, error) {
 			sch[k] = v
 		}
 	}
-	AdditionalData["ScholieDistanceExact"] = sch
+	c.Set("ScholieDistanceExact", sch)
 	return sch, nil
 }
 
 // ScholieDistanceExact computes the distance based onscholie
-func ScholieDistanceExact(e Edit, sch map[string]interface{}) float64 {
+func ScholieDistanceExact(e Edit, ctx *Context) float64 {
 	from, to := getWords(e)
 	source, target := sumWords(from), sumWords(to)
-	scholie := sch["ScholieDistanceExact"].(map[string][]string)
+	scholie := ctx.voc("ScholieDistanceExact")
 
 	entry := source.Text
 
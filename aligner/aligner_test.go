@@ -2,6 +2,7 @@ package aligner
 
 import (
 	"fmt"
+	"runtime"
 	"strings"
 	"testing"
 )
@@ -38,3 +39,158 @@ func TestTrie(t *testing.T) {
 	}
 
 }
+
+func TestSortProblemIDs(t *testing.T) {
+	ids := []string{"2.1", "1.10", "10.1", "1.2", "1.1"}
+	SortProblemIDs(ids)
+	expected := []string{"1.1", "1.2", "1.10", "2.1", "10.1"}
+	for i := range ids {
+		if ids[i] != expected[i] {
+			t.Error("Expected ", expected, " got ", ids)
+			break
+		}
+	}
+}
+
+func TestEditsOrder(t *testing.T) {
+	h1 := Word{ID: "HOM.1", Text: "μῆνιν"}
+	h2 := Word{ID: "HOM.2", Text: "ἄειδε"}
+	p1 := Word{ID: "PARA.1", Text: "τὴν"}
+	p2 := Word{ID: "PARA.2", Text: "ὀργὴν"}
+	p3 := Word{ID: "PARA.3", Text: "εἰπὲ"}
+	p4 := Word{ID: "PARA.4", Text: "ἡμῖν"}
+
+	a := NewFromEdits(&Ins{W: p4}, &Sub{From: []Word{h2}, To: []Word{p3}}, &Ins{W: p1}, &Sub{From: []Word{h1}, To: []Word{p2}})
+	expected := []string{"Ins(τὴν)", "Sub(μῆνιν , ὀργὴν )", "Sub(ἄειδε , εἰπὲ )", "Ins(ἡμῖν)"}
+	edits := a.Edits()
+	if len(edits) != len(expected) {
+		t.Fatal("Expected ", len(expected), " edits got ", len(edits))
+	}
+	for i, e := range edits {
+		if e.String() != expected[i] {
+			t.Error("Expected ", expected[i], " got ", e)
+		}
+	}
+}
+
+func TestMergeAlignments(t *testing.T) {
+	as := []*Alignment{}
+	for i := 1; i <= 3; i++ {
+		w := Word{ID: GetWordID("HOM", fmt.Sprint(i)), Text: fmt.Sprint("h", i), Source: "HOM"}
+		as = append(as, NewFromEdits(&Del{W: w}))
+	}
+	tt := []struct {
+		as    []*Alignment
+		edits int
+	}{
+		{as: nil, edits: 0},
+		{as: as[:1], edits: 1},
+		{as: as, edits: 3},
+		{as: append(as, as[0]), edits: 3},
+	}
+	for _, v := range tt {
+		if n := len(MergeAlignments(v.as...).Edits()); n != v.edits {
+			t.Error("Expected ", v.edits, " edits got ", n)
+		}
+	}
+	if len(as[0].Edits()) != 1 {
+		t.Error("Expected the merged alignments unchanged got ", as[0])
+	}
+}
+
+func TestNewViewerAlignment(t *testing.T) {
+	h1 := Word{ID: "HOM.1", Text: "μῆνιν", Chant: "1", Verse: "1", Source: "HOM"}
+	p1 := Word{ID: "PARA.1", Text: "τὴν", Chant: "1", Verse: "1", Source: "PARA"}
+	p2 := Word{ID: "PARA.2", Text: "ὀργὴν", Chant: "1", Verse: "1", Source: "PARA"}
+	h2 := Word{ID: "HOM.6", Text: "οὐλομένην", Chant: "1", Verse: "2", Source: "HOM"}
+
+	a := MergeAlignments(
+		NewFromEdits(&Sub{From: []Word{h1}, To: []Word{p1, p2}}),
+		NewFromEdits(&Del{W: h2}),
+	)
+	va := NewViewerAlignment(a)
+
+	if len(va.Verses) != 2 || va.Verses[0] != "1.1" || va.Verses[1] != "1.2" {
+		t.Error("Expected verses [1.1 1.2] got ", va.Verses)
+	}
+	if len(va.Words) != 4 || va.Words["PARA.2"].Text != "ὀργὴν" {
+		t.Error("Expected 4 words got ", va.Words)
+	}
+	if e := va.Left["HOM.1"]; e.Type != "sub" || len(e.Source) != 0 || len(e.Target) != 2 {
+		t.Error("Expected sub with 2 targets for HOM.1 got ", e)
+	}
+	if e := va.Right["PARA.1"]; e.Type != "sub" || len(e.Source) != 1 || e.Source[0] != "PARA.2" || e.Target[0] != "HOM.1" {
+		t.Error("Expected sub with PARA.2 source for PARA.1 got ", e)
+	}
+	if e := va.Left["HOM.6"]; e.Type != "del" {
+		t.Error("Expected del for HOM.6 got ", e)
+	}
+}
+
+func TestAlignDeterministicTies(t *testing.T) {
+	from := WordsBag{}
+	to := WordsBag{}
+	for i := 1; i <= 4; i++ {
+		w := Word{ID: GetWordID("HOM", fmt.Sprint(i)), Text: fmt.Sprint("h", i), Source: "HOM"}
+		from[w.ID] = w
+	}
+	for i := 1; i <= 5; i++ {
+		w := Word{ID: GetWordID("PARA", fmt.Sprint(i)), Text: fmt.Sprint("p", i), Source: "PARA"}
+		to[w.ID] = w
+	}
+
+	expected := ""
+	for _, workers := range []int{1, 4, 1, 4, 1, 4} {
+		ctx := NewContext()
+		ctx.SetWorkers(workers)
+		a, err := NewFromWordBags(from, to).Align(NewGreekAligner(), []Feature{EditType}, []float64{1.0}, 1, ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected == "" {
+			expected = a.key()
+		}
+		if a.key() != expected {
+			t.Error("Expected ", expected, " got ", a.key())
+		}
+	}
+}
+
+// BenchmarkAlign aligns a long verse scoring the candidates with one worker and
+// with several of them
+func BenchmarkAlign(b *testing.B) {
+	words := func(source string, n int) WordsBag {
+		ws := WordsBag{}
+		for i := 1; i <= n; i++ {
+			w := Word{
+				ID:     GetWordID(source, fmt.Sprint(i)),
+				Text:   fmt.Sprint("w", i%17, "o", i%5),
+				Lemma:  fmt.Sprint("l", i%13),
+				Tag:    fmt.Sprint("N:", i%3),
+				Source: source,
+			}
+			ws[w.ID] = w
+		}
+		return ws
+	}
+	from, to := words("HOM", 30), words("PARA", 40)
+	fs := []Feature{TextualDistance, TagDistance, EditType}
+	ws := []float64{1, 0.5, 0.1}
+
+	workers := []int{1, 4}
+	if n := runtime.NumCPU(); n > 4 {
+		workers = append(workers, n)
+	}
+	for _, workers := range workers {
+		b.Run(fmt.Sprint("workers-", workers), func(b *testing.B) {
+			ctx := NewContext()
+			ctx.SetWorkers(workers)
+			for i := 0; i < b.N; i++ {
+				ctx.ResetCache()
+				if _, err := NewFromWordBags(from, to).Align(NewGreekAligner(), fs, ws, 1, ctx); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package aligner
+
+import "math"
+
+// DefaultAssociationSmoothing is the smoothing of the lemma association scores
+// used by the commands
+const DefaultAssociationSmoothing = 1.0
+
+// LemmaAssociation counts the HOM and PARA lemmas linked together by a set of gold
+// alignments, to score how strongly they are associated
+type LemmaAssociation struct {
+	Smoothing float64                   `json:"smoothing"`
+	Pairs     map[string]map[string]int `json:"pairs"` // links by HOM and then PARA lemma
+	Links     int                       `json:"links"`
+	Hom       map[string]int            `json:"hom"`  // occurrences of the HOM lemmas
+	Para      map[string]int            `json:"para"` // occurrences of the PARA lemmas
+	HomWords  int                       `json:"homWords"`
+	ParaWords int                       `json:"paraWords"`
+}
+
+// NewLemmaAssociation counts the lemmas of the gold alignments. During an experiment
+// only the alignments of the training set must be given, so that the test verses
+// do not leak into the feature.
+func NewLemmaAssociation(gold []*Alignment, smoothing float64) *LemmaAssociation {
+	la := &LemmaAssociation{
+		Smoothing: smoothing,
+		Pairs:     map[string]map[string]int{},
+		Hom:       map[string]int{},
+		Para:      map[string]int{},
+	}
+	for _, a := range gold {
+		for _, e := range a.editMap {
+			from, to := getWords(e)
+			for _, w := range from {
+				if w.Lemma != "" {
+					la.Hom[w.Lemma]++
+					la.HomWords++
+				}
+			}
+			for _, w := range to {
+				if w.Lemma != "" {
+					la.Para[w.Lemma]++
+					la.ParaWords++
+				}
+			}
+			if len(from) == 0 || len(to) == 0 {
+				continue
+			}
+			for _, h := range from {
+				for _, p := range to {
+					if h.Lemma == "" || p.Lemma == "" {
+						continue
+					}
+					if la.Pairs[h.Lemma] == nil {
+						la.Pairs[h.Lemma] = map[string]int{}
+					}
+					la.Pairs[h.Lemma][p.Lemma]++
+					la.Links++
+				}
+			}
+		}
+	}
+	return la
+}
+
+// Without returns the association with the counts of the gold alignment removed,
+// as if it had been built without it. During the training each verse is aligned
+// with the association of the others, so that its own links do not inflate the
+// weights of the features.
+func (la *LemmaAssociation) Without(a *Alignment) *LemmaAssociation {
+	own := NewLemmaAssociation([]*Alignment{a}, la.Smoothing)
+	res := &LemmaAssociation{
+		Smoothing: la.Smoothing,
+		Pairs:     make(map[string]map[string]int, len(la.Pairs)),
+		Links:     la.Links - own.Links,
+		Hom:       subtractCounts(la.Hom, own.Hom),
+		Para:      subtractCounts(la.Para, own.Para),
+		HomWords:  la.HomWords - own.HomWords,
+		ParaWords: la.ParaWords - own.ParaWords,
+	}
+	// the maps of the HOM lemmas not in the alignment are shared
+	for h, ps := range la.Pairs {
+		res.Pairs[h] = ps
+	}
+	for h, ps := range own.Pairs {
+		res.Pairs[h] = subtractCounts(la.Pairs[h], ps)
+		if len(res.Pairs[h]) == 0 {
+			delete(res.Pairs, h)
+		}
+	}
+	return res
+}
+
+// subtractCounts returns a copy of the counts minus the removed ones, without
+// the keys left at 0
+func subtractCounts(counts, removed map[string]int) map[string]int {
+	res := make(map[string]int, len(counts))
+	for k, c := range counts {
+		if c -= removed[k]; c > 0 {
+			res[k] = c
+		}
+	}
+	return res
+}
+
+// Dice returns 2 c(h,p) / (c(h) + c(p) + 2 smoothing), where c(h,p) is the number
+// of links between the lemmas and c(h), c(p) their occurrences. The smoothing
+// lowers the score of the rare lemmas.
+func (la *LemmaAssociation) Dice(hom, para string) float64 {
+	c := la.Pairs[hom][para]
+	if c == 0 {
+		return 0
+	}
+	return math.Min(1, 2*float64(c)/(float64(la.Hom[hom]+la.Para[para])+2*la.Smoothing))
+}
+
+// PMI returns the normalized pointwise mutual information of the lemmas, in
+// [-1, 1], with the probabilities of each lemma smoothed by adding the smoothing
+// to its occurrences. It is 0 for lemmas never linked.
+func (la *LemmaAssociation) PMI(hom, para string) float64 {
+	c := la.Pairs[hom][para]
+	if c == 0 {
+		return 0
+	}
+	joint := float64(c) / float64(la.Links)
+	if joint >= 1 {
+		return 1
+	}
+	ph := (float64(la.Hom[hom]) + la.Smoothing) / (float64(la.HomWords) + la.Smoothing*float64(len(la.Hom)))
+	pp := (float64(la.Para[para]) + la.Smoothing) / (float64(la.ParaWords) + la.Smoothing*float64(len(la.Para)))
+	npmi := math.Log(joint/(ph*pp)) / -math.Log(joint)
+	return math.Max(-1, math.Min(1, npmi))
+}
+
+// LemmaDice scores the edit with the Dice coefficient of its lemmas in the gold alignments
+func LemmaDice(e Edit, ctx *Context) float64 {
+	return associationScore(e, ctx, "LemmaDice", (*LemmaAssociation).Dice)
+}
+
+// LemmaPMI scores the edit with the normalized PMI of its lemmas in the gold alignments
+func LemmaPMI(e Edit, ctx *Context) float64 {
+	return associationScore(e, ctx, "LemmaPMI", (*LemmaAssociation).PMI)
+}
+
+// associationScore is the mean over the PARA words of the edit of their best
+// score with one of its HOM words, 0 for Ins and Del
+func associationScore(e Edit, ctx *Context, funcName string, score func(*LemmaAssociation, string, string) float64) float64 {
+	if v, ok := ctx.cachedScore(funcName, e); ok {
+		return v
+	}
+	r, _ := ctx.Resource("LemmaAssociation")
+	la := r.(*LemmaAssociation)
+
+	from, to := getWords(e)
+	res := 0.0
+	if len(from) > 0 && len(to) > 0 {
+		for _, p := range to {
+			best := math.Inf(-1)
+			for _, h := range from {
+				best = math.Max(best, score(la, h.Lemma, p.Lemma))
+			}
+			res += best
+		}
+		res /= float64(len(to))
+	}
+	ctx.cacheScore(funcName, e, res)
+	return res
+}
@@ -0,0 +1,70 @@
+package aligner
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestLemmaAssociation(t *testing.T) {
+	hom := func(id, lemma string) Word { return Word{ID: "HOM." + id, Lemma: lemma, Source: "HOM"} }
+	para := func(id, lemma string) Word { return Word{ID: "PARA." + id, Lemma: lemma, Source: "PARA"} }
+
+	gold := []*Alignment{
+		NewFromEdits(
+			&Sub{From: []Word{hom("1", "μῆνις")}, To: []Word{para("1", "ὀργή")}},
+			&Eq{From: hom("2", "θεά"), To: para("2", "θεά")},
+			&Ins{W: para("3", "ὁ")},
+		),
+		NewFromEdits(
+			&Sub{From: []Word{hom("3", "μῆνις")}, To: []Word{para("4", "ὀργή")}},
+			&Del{W: hom("4", "τε")},
+		),
+		NewFromEdits(
+			&Sub{From: []Word{hom("5", "μῆνις"), hom("6", "θεά")}, To: []Word{para("5", "θυμός")}},
+		),
+	}
+	la := NewLemmaAssociation(gold, 1)
+	if la.Links != 5 || la.Hom["μῆνις"] != 3 || la.Para["ὁ"] != 1 || la.Pairs["μῆνις"]["ὀργή"] != 2 {
+		t.Fatal("Unexpected counts ", la)
+	}
+
+	tt := []struct {
+		hom, para string
+		dice      float64
+	}{
+		{"μῆνις", "ὀργή", 2.0 * 2 / (3 + 2 + 2)},
+		{"θεά", "θεά", 2.0 * 1 / (2 + 1 + 2)},
+		{"μῆνις", "θυμός", 2.0 * 1 / (3 + 1 + 2)},
+		{"τε", "ὁ", 0},
+	}
+	for _, x := range tt {
+		if d := la.Dice(x.hom, x.para); math.Abs(d-x.dice) > 1e-9 {
+			t.Error("Expected Dice ", x.dice, " got ", d, " for ", x.hom, " ", x.para)
+		}
+		if p := la.PMI(x.hom, x.para); p < -1 || p > 1 || (x.dice == 0) != (p == 0) {
+			t.Error("Expected PMI in [-1, 1], 0 only for unlinked lemmas, got ", p, " for ", x.hom, " ", x.para)
+		}
+	}
+	if la.PMI("μῆνις", "ὀργή") <= la.PMI("μῆνις", "θυμός") {
+		t.Error("Expected μῆνις to be more associated with ὀργή than with θυμός")
+	}
+
+	without := la.Without(gold[0])
+	if expected := NewLemmaAssociation(gold[1:], 1); !reflect.DeepEqual(without, expected) {
+		t.Error("Expected ", expected, " got ", without)
+	}
+	if la.Pairs["μῆνις"]["ὀργή"] != 2 || la.Links != 5 {
+		t.Error("Expected Without to leave the association unchanged got ", la)
+	}
+
+	ctx := NewContext()
+	ctx.Set("LemmaAssociation", la)
+	e := &Sub{From: []Word{hom("7", "μῆνις"), hom("8", "τε")}, To: []Word{para("7", "ὀργή"), para("8", "ὁ")}}
+	if v, expected := LemmaDice(e, ctx), la.Dice("μῆνις", "ὀργή")/2; math.Abs(v-expected) > 1e-9 {
+		t.Error("Expected ", expected, " got ", v)
+	}
+	if v := LemmaPMI(&Ins{W: para("9", "ὁ")}, ctx); v != 0 {
+		t.Error("Expected 0 for Ins got ", v)
+	}
+}
@@ -0,0 +1,85 @@
+package aligner
+
+import (
+	"fmt"
+	"sort"
+)
+
+// searcher is implemented by the aligners exploring the candidate alignments
+// themselves instead of using the greedy search of Align
+type searcher interface {
+	search(a *Alignment, fs []Feature, ws []float64, subseqLen int, ctx *Context) (*Alignment, error)
+}
+
+type beamAligner struct {
+	greekAligner
+	width int
+}
+
+// NewBeamAligner creates an aligner keeping the best width partial alignments
+// at each step instead of the single best one
+func NewBeamAligner(width int) *beamAligner {
+	if width < 1 {
+		width = 1
+	}
+	return &beamAligner{width: width}
+}
+
+func (ar *beamAligner) search(a *Alignment, fs []Feature, ws []float64, subseqLen int, ctx *Context) (*Alignment, error) {
+	complete := ar.beamSearch(a, fs, ws, subseqLen, ctx)
+	if len(complete) == 0 {
+		return nil, fmt.Errorf("beam search found no alignment")
+	}
+	return &complete[0], nil
+}
+
+// beamSearch returns the complete alignments found, sorted by decreasing score
+func (ar *beamAligner) beamSearch(a *Alignment, fs []Feature, ws []float64, subseqLen int, ctx *Context) []Alignment {
+	beam := []Alignment{*a}
+	complete := []Alignment{}
+	for len(beam) > 0 {
+		candidates := []Alignment{}
+		seen := map[string]bool{}
+		for i := range beam {
+			F := ar.next(&beam[i], subseqLen)
+			if len(F) == 0 {
+				complete = append(complete, beam[i])
+				continue
+			}
+			for _, c := range F {
+				if k := c.key(); !seen[k] {
+					seen[k] = true
+					candidates = append(candidates, c)
+				}
+			}
+		}
+		beam = bestCandidates(candidates, scoreCandidates(candidates, fs, ws, ctx), ar.width)
+	}
+	return bestCandidates(complete, scoreCandidates(complete, fs, ws, ctx), len(complete))
+}
+
+// bestCandidates returns the n alignments with the highest scores, with ties
+// broken by the alignment key as in bestCandidate
+func bestCandidates(F []Alignment, scores []float64, n int) []Alignment {
+	idx := make([]int, len(F))
+	keys := make([]string, len(F))
+	for i := range F {
+		idx[i] = i
+		keys[i] = F[i].key()
+	}
+	sort.SliceStable(idx, func(i, j int) bool {
+		x, y := idx[i], idx[j]
+		if scores[x] != scores[y] {
+			return scores[x] > scores[y]
+		}
+		return keys[x] < keys[y]
+	})
+	if n > len(idx) {
+		n = len(idx)
+	}
+	res := make([]Alignment, n)
+	for i := range res {
+		res[i] = F[idx[i]]
+	}
+	return res
+}
@@ -0,0 +1,52 @@
+package aligner
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestBeamAligner(t *testing.T) {
+	from := WordsBag{}
+	to := WordsBag{}
+	for i, text := range []string{"menin", "aeide", "thea", "peleiadeo"} {
+		w := Word{ID: GetWordID("HOM", fmt.Sprint(i+1)), Text: text, Source: "HOM"}
+		from[w.ID] = w
+	}
+	for i, text := range []string{"ten", "orgen", "eipe", "thea", "tou", "peleidou"} {
+		w := Word{ID: GetWordID("PARA", fmt.Sprint(i+1)), Text: text, Source: "PARA"}
+		to[w.ID] = w
+	}
+	fs := []Feature{LexicalSimilarity, EditType}
+	ws := []float64{1.0, 0.1}
+
+	greedy, err := NewFromWordBags(from, to).Align(NewGreekAligner(), fs, ws, 2, NewContext())
+	if err != nil {
+		t.Fatal(err)
+	}
+	greedyScore := greedy.Score(fs, ws, NewContext())
+
+	tt := []struct {
+		width int
+	}{
+		{width: 1},
+		{width: 3},
+		{width: 10},
+	}
+	for _, v := range tt {
+		ctx := NewContext()
+		a, err := NewFromWordBags(from, to).Align(NewBeamAligner(v.width), fs, ws, 2, ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v.width == 1 && a.key() != greedy.key() {
+			t.Error("Expected ", greedy.key(), " got ", a.key())
+		}
+		if s := a.Score(fs, ws, ctx); s < greedyScore {
+			t.Error("Expected score of width ", v.width, " >= ", greedyScore, " got ", s)
+		}
+		if len(a.filter(reflect.TypeOf(&Del{}))) != 0 {
+			t.Error("Expected complete alignment got ", a)
+		}
+	}
+}
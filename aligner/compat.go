@@ -0,0 +1,56 @@
+package aligner
+
+import "github.com/szenzaro/iliad-aligner/vectors"
+
+// MapFeature is the former signature of the features, reading their resources
+// from a map by name
+type MapFeature func(Edit, map[string]interface{}) float64
+
+// AdditionalData holds the resources loaded by the package level functions
+// LoadVoc, LoadScholie and LoadScholieDict.
+//
+// Deprecated: the resources are held by DefaultContext, changes to this map are
+// not seen by the features.
+var AdditionalData map[string]interface{}
+
+// syncAdditionalData copies the resources of DefaultContext to AdditionalData
+func syncAdditionalData() {
+	AdditionalData = map[string]interface{}{}
+	for k, v := range DefaultContext.Data() {
+		AdditionalData[k] = v
+	}
+}
+
+// FromMapFeature adapts a feature written for the former signature to a
+// Feature. The map of the resources is built once for each context, the feature
+// must not modify it and must cache its scores by itself.
+func FromMapFeature(f MapFeature) Feature {
+	return func(e Edit, ctx *Context) float64 {
+		return f(e, ctx.Data())
+	}
+}
+
+// FromMapFeatures adapts each feature with FromMapFeature
+func FromMapFeatures(fs []MapFeature) []Feature {
+	res := make([]Feature, len(fs))
+	for i, f := range fs {
+		res[i] = FromMapFeature(f)
+	}
+	return res
+}
+
+// AlignWithData aligns with the former signature of Align, in a new context
+// holding the resources of data.
+//
+// Deprecated: use Align with a Context.
+func (a *Alignment) AlignWithData(ar Aligner, fs []MapFeature, ws []float64, subseqLen int, data map[string]interface{}) (*Alignment, error) {
+	return a.Align(ar, FromMapFeatures(fs), ws, subseqLen, NewContextFromData(data))
+}
+
+// PhiWithData computes Phi with the former signature, in a new context holding
+// the resources of data.
+//
+// Deprecated: use Phi with a Context.
+func PhiWithData(a *Alignment, fs []MapFeature, data map[string]interface{}) vectors.Vector {
+	return Phi(a, FromMapFeatures(fs), NewContextFromData(data))
+}
@@ -0,0 +1,194 @@
+package aligner
+
+import (
+	"reflect"
+	"runtime"
+	"sync"
+
+	trie "github.com/derekparker/trie"
+)
+
+// Context owns the resources needed to compute the features (dictionaries,
+// scholie trie, ...) and the caches of the computed scores.
+// It is safe for concurrent use.
+type Context struct {
+	mu        sync.RWMutex
+	resources map[string]interface{}
+	data      map[string]interface{} // copy of the resources returned by Data
+	scores    map[string]*scoreCache // by feature name
+	scholie   map[string][]string    // scholie entries by prefix
+	workers   int
+}
+
+// DefaultContext is the context used by the package level loading functions
+// LoadVoc, LoadScholie, LoadScholieDict and by ResetCache
+var DefaultContext = NewContext()
+
+// NewContext creates an empty context scoring the candidate alignments with
+// all the CPUs, see SetWorkers
+func NewContext() *Context {
+	return &Context{
+		resources: map[string]interface{}{},
+		scores:    map[string]*scoreCache{},
+		scholie:   map[string][]string{},
+		workers:   runtime.NumCPU(),
+	}
+}
+
+// NewContextFromData creates a context with the given resources
+func NewContextFromData(data map[string]interface{}) *Context {
+	c := NewContext()
+	for k, v := range data {
+		c.resources[k] = v
+	}
+	return c
+}
+
+// Fork creates a context sharing the resources and settings of c with empty caches
+func (c *Context) Fork() *Context {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	f := NewContextFromData(c.resources)
+	f.workers = c.workers
+	return f
+}
+
+// SetWorkers sets the number of goroutines used to score the candidate
+// alignments at each step of Align, a non positive value uses all the CPUs
+func (c *Context) SetWorkers(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if n <= 0 {
+		n = runtime.NumCPU()
+	}
+	c.workers = n
+}
+
+// Workers returns the number of goroutines used to score the candidate alignments
+func (c *Context) Workers() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.workers
+}
+
+// Set stores a resource in the context
+func (c *Context) Set(name string, resource interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.resources[name] = resource
+	c.data = nil
+}
+
+// Resource returns the resource stored with the given name
+func (c *Context) Resource(name string) (interface{}, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	r, ok := c.resources[name]
+	return r, ok
+}
+
+// Data returns the resources of the context by name. The map is built once
+// until the next Set and must not be modified.
+func (c *Context) Data() map[string]interface{} {
+	c.mu.RLock()
+	data := c.data
+	c.mu.RUnlock()
+	if data != nil {
+		return data
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.data == nil {
+		c.data = make(map[string]interface{}, len(c.resources))
+		for k, v := range c.resources {
+			c.data[k] = v
+		}
+	}
+	return c.data
+}
+
+// ResetCache clears the caches of the context
+func (c *Context) ResetCache() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.scores = map[string]*scoreCache{}
+	c.scholie = map[string][]string{}
+}
+
+func (c *Context) voc(name string) map[string][]string {
+	r, _ := c.Resource(name)
+	return r.(map[string][]string)
+}
+
+func (c *Context) scholieTrie() *trie.Trie {
+	r, _ := c.Resource("ScholieDistance")
+	return r.(*trie.Trie)
+}
+
+// cacheShards is the number of independently locked parts of the score cache
+// of a feature, so that the scoring workers seldom wait for each other
+const cacheShards = 32
+
+// scoreCache holds the scores of a feature by edit, split in shards by the
+// address of the edit
+type scoreCache [cacheShards]struct {
+	sync.RWMutex
+	scores map[Edit]float64
+}
+
+func (sc *scoreCache) shard(e Edit) int {
+	// Fibonacci hashing spreads the aligned addresses over the shards
+	return int((uint64(reflect.ValueOf(e).Pointer()) * 11400714819323198485) >> 59)
+}
+
+// featureCache returns the score cache of the feature, creating it if asked to
+func (c *Context) featureCache(funcName string, create bool) *scoreCache {
+	c.mu.RLock()
+	sc := c.scores[funcName]
+	c.mu.RUnlock()
+	if sc != nil || !create {
+		return sc
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.scores[funcName] == nil {
+		c.scores[funcName] = &scoreCache{}
+	}
+	return c.scores[funcName]
+}
+
+func (c *Context) cachedScore(funcName string, e Edit) (float64, bool) {
+	sc := c.featureCache(funcName, false)
+	if sc == nil {
+		return 0, false
+	}
+	s := &sc[sc.shard(e)]
+	s.RLock()
+	defer s.RUnlock()
+	v, ok := s.scores[e]
+	return v, ok
+}
+
+func (c *Context) cacheScore(funcName string, e Edit, v float64) {
+	sc := c.featureCache(funcName, true)
+	s := &sc[sc.shard(e)]
+	s.Lock()
+	defer s.Unlock()
+	if s.scores == nil {
+		s.scores = map[Edit]float64{}
+	}
+	s.scores[e] = v
+}
+
+func (c *Context) cachedScholieEntries(entry string) ([]string, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	v, ok := c.scholie[entry]
+	return v, ok
+}
+
+func (c *Context) cacheScholieEntries(entry string, entries []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.scholie[entry] = entries
+}
@@ -0,0 +1,118 @@
+package aligner
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"sync"
+	"testing"
+)
+
+func TestContextConcurrentScoring(t *testing.T) {
+	ctx := NewContext()
+	if _, err := ctx.LoadScholie("../data/scholied.json"); err != nil {
+		t.Fatal(err)
+	}
+
+	h := Word{ID: "HOM.4", Text: "πηληιάδεω", Lemma: "Πηλεΐδης", Tag: "N+Pat:Gms"}
+	p := Word{ID: "PARA.8", Text: "υἱοῦ", Lemma: "υἱός", Tag: "N+Com:Gms"}
+	e := &Sub{From: []Word{h}, To: []Word{p}}
+	fs := []Feature{TextualDistance, TagDistance, ScholieDistance}
+	ws := []float64{0.5, 0.3, 0.2}
+	expected := e.Score(fs, ws, NewContextFromData(map[string]interface{}{"ScholieDistance": ctx.scholieTrie()}))
+
+	var wg sync.WaitGroup
+	scores := make([]float64, 16)
+	for i := range scores {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			c := ctx
+			if i%2 == 0 {
+				c = ctx.Fork()
+			}
+			scores[i] = e.Score(fs, ws, c)
+		}(i)
+	}
+	wg.Wait()
+
+	for _, s := range scores {
+		if s != expected {
+			t.Error("Expected ", expected, " got ", s)
+		}
+	}
+}
+
+func TestContextWorkers(t *testing.T) {
+	ctx := NewContext()
+	if w := ctx.Workers(); w != runtime.NumCPU() {
+		t.Error("Expected ", runtime.NumCPU(), " scoring workers by default got ", w)
+	}
+	ctx.SetWorkers(3)
+	if w := ctx.Fork().Workers(); w != 3 {
+		t.Error("Expected the fork to keep 3 workers got ", w)
+	}
+}
+
+func TestFromMapFeature(t *testing.T) {
+	voc := map[string][]string{"a": {"b"}}
+	f := FromMapFeature(func(e Edit, data map[string]interface{}) float64 {
+		if _, ok := data["VocDistance"].(map[string][]string); !ok {
+			return -1
+		}
+		return EditType(e, nil)
+	})
+	ctx := NewContextFromData(map[string]interface{}{"VocDistance": voc})
+	tt := []struct {
+		e   Edit
+		res float64
+	}{
+		{e: &Ins{}, res: 1},
+		{e: &Eq{}, res: 10},
+	}
+	for _, k := range tt {
+		if res := f(k.e, ctx); res != k.res {
+			t.Error("Expected ", k.res, " got ", res)
+		}
+	}
+	if res := f(&Eq{}, NewContext()); res != -1 {
+		t.Error("Expected -1 without the resource got ", res)
+	}
+
+	data := ctx.Data()
+	if reflect.ValueOf(ctx.Data()).Pointer() != reflect.ValueOf(data).Pointer() {
+		t.Error("Expected the resources map to be built once")
+	}
+	ctx.Set("EquivDistance", voc)
+	if _, ok := ctx.Data()["EquivDistance"]; !ok {
+		t.Error("Expected the resources map to be rebuilt after Set")
+	}
+}
+
+func TestAlignWithData(t *testing.T) {
+	from := WordsBag{}
+	to := WordsBag{}
+	for i, text := range []string{"menin", "aeide", "thea"} {
+		w := Word{ID: GetWordID("HOM", fmt.Sprint(i+1)), Text: text, Source: "HOM"}
+		from[w.ID] = w
+	}
+	for i, text := range []string{"orgen", "eipe", "thea"} {
+		w := Word{ID: GetWordID("PARA", fmt.Sprint(i+1)), Text: text, Source: "PARA"}
+		to[w.ID] = w
+	}
+	old := []MapFeature{func(e Edit, data map[string]interface{}) float64 { return EditType(e, nil) }}
+	expected, err := NewFromWordBags(from, to).Align(NewGreekAligner(), []Feature{EditType}, []float64{1}, 1, NewContext())
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := NewFromWordBags(from, to).AlignWithData(NewGreekAligner(), old, []float64{1}, 1, map[string]interface{}{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.key() != expected.key() {
+		t.Error("Expected ", expected, " got ", a)
+	}
+	if phi := PhiWithData(a, old, nil); phi[0] != Phi(a, []Feature{EditType}, NewContext())[0] {
+		t.Error("Expected the same Phi got ", phi)
+	}
+}
@@ -0,0 +1,162 @@
+package aligner
+
+import (
+	"sort"
+	"strings"
+)
+
+// Categories of the differences between the predicted and the gold edit of a word
+const (
+	CategoryCorrect     = "correct"
+	CategoryMissedEq    = "missed Eq"
+	CategorySpuriousEq  = "spurious Eq"
+	CategoryMissedIns   = "missed Ins"
+	CategoryMissedDel   = "missed Del"
+	CategorySpuriousIns = "spurious Ins"
+	CategorySpuriousDel = "spurious Del"
+	CategoryShortSub    = "Sub span too short"
+	CategoryLongSub     = "Sub span too long"
+	CategoryWrongSub    = "wrong Sub span"
+	CategoryUnaligned   = "unaligned"
+	CategoryNotInGold   = "not in gold"
+)
+
+// EditTypeName returns the type of the edit: "Ins", "Del", "Eq", "Sub" or "none" for nil
+func EditTypeName(e Edit) string {
+	switch e.(type) {
+	case *Ins:
+		return "Ins"
+	case *Del:
+		return "Del"
+	case *Eq:
+		return "Eq"
+	case *Sub:
+		return "Sub"
+	}
+	return "none"
+}
+
+// WordDiff holds the edits aligning a word in the gold and in the predicted alignment
+type WordDiff struct {
+	Word      Word
+	Gold      Edit // nil when the word is not in the gold alignment
+	Predicted Edit // nil when the word is not in the predicted alignment
+}
+
+// DiffWords compares the edit of each word of the gold alignment with the
+// predicted one, followed by the words that are only in the predicted alignment.
+// The result follows the order of the HOM words then of the PARA words.
+func DiffWords(gold, predicted *Alignment) []WordDiff {
+	g, p := gold.editsByWord(), predicted.editsByWord()
+	diffs := []WordDiff{}
+	add := func(id string, e Edit) {
+		d := WordDiff{Gold: g[id], Predicted: p[id]}
+		for _, w := range editWords(e) {
+			if w.ID == id {
+				d.Word = w
+			}
+		}
+		diffs = append(diffs, d)
+	}
+	for id, e := range g {
+		add(id, e)
+	}
+	for id, e := range p {
+		if _, ok := g[id]; !ok {
+			add(id, e)
+		}
+	}
+	sort.Slice(diffs, func(i, j int) bool {
+		x, y := diffs[i].Word, diffs[j].Word
+		if hx, hy := strings.HasPrefix(x.ID, "HOM"), strings.HasPrefix(y.ID, "HOM"); hx != hy {
+			return hx
+		}
+		return wordIndex(x.ID) < wordIndex(y.ID)
+	})
+	return diffs
+}
+
+// editsByWord maps the ID of each word to the edit aligning it
+func (a *Alignment) editsByWord() map[string]Edit {
+	res := map[string]Edit{}
+	for _, e := range a.editMap {
+		for _, w := range editWords(e) {
+			res[w.ID] = e
+		}
+	}
+	return res
+}
+
+// Category classifies the difference between the predicted and the gold edit
+func (d WordDiff) Category() string {
+	g, p := EditTypeName(d.Gold), EditTypeName(d.Predicted)
+	switch {
+	case d.Gold == nil:
+		return CategoryNotInGold
+	case d.Predicted == nil:
+		return CategoryUnaligned
+	case sameWords(d.Gold, d.Predicted) && g == p:
+		return CategoryCorrect
+	case g == "Eq":
+		return CategoryMissedEq
+	case p == "Eq":
+		return CategorySpuriousEq
+	case p == "Ins":
+		return CategorySpuriousIns
+	case p == "Del":
+		return CategorySpuriousDel
+	case g == "Ins":
+		return CategoryMissedIns
+	case g == "Del":
+		return CategoryMissedDel
+	}
+
+	// both are Sub with different words
+	gw, pw := wordIDs(d.Gold), wordIDs(d.Predicted)
+	switch {
+	case includesAll(gw, pw):
+		return CategoryShortSub
+	case includesAll(pw, gw):
+		return CategoryLongSub
+	}
+	return CategoryWrongSub
+}
+
+// editWords returns the HOM and PARA words of the edit
+func editWords(e Edit) []Word {
+	from, to := getWords(e)
+	return append(append([]Word{}, from...), to...)
+}
+
+// EditWords returns copies of the HOM and of the PARA words of the edit, in text order
+func EditWords(e Edit) ([]Word, []Word) {
+	from, to := getWords(e)
+	from, to = append([]Word{}, from...), append([]Word{}, to...)
+	for _, ws := range [][]Word{from, to} {
+		sort.Slice(ws, func(i, j int) bool { return wordIndex(ws[i].ID) < wordIndex(ws[j].ID) })
+	}
+	return from, to
+}
+
+func wordIDs(e Edit) map[string]bool {
+	ids := map[string]bool{}
+	for _, w := range editWords(e) {
+		ids[w.ID] = true
+	}
+	return ids
+}
+
+func sameWords(a, b Edit) bool {
+	x, y := wordIDs(a), wordIDs(b)
+	return len(x) == len(y) && includesAll(x, y)
+}
+
+// includesAll checks that every ID of sub is in set
+func includesAll(set, sub map[string]bool) bool {
+	for id := range sub {
+		if !set[id] {
+			return false
+		}
+	}
+	return true
+}
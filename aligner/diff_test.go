@@ -0,0 +1,89 @@
+package aligner
+
+import "testing"
+
+func TestDiffWords(t *testing.T) {
+	h1 := Word{ID: "HOM.1", Text: "menin"}
+	h2 := Word{ID: "HOM.2", Text: "aeide"}
+	h3 := Word{ID: "HOM.3", Text: "thea"}
+	p1 := Word{ID: "PARA.1", Text: "ten"}
+	p2 := Word{ID: "PARA.2", Text: "orgen"}
+	p3 := Word{ID: "PARA.3", Text: "thea"}
+
+	tt := []struct {
+		gold, predicted *Alignment
+		categories      map[string]string
+	}{
+		{
+			gold:       NewFromEdits(&Sub{From: []Word{h1}, To: []Word{p1, p2}}, &Eq{From: h3, To: p3}, &Del{W: h2}),
+			predicted:  NewFromEdits(&Sub{From: []Word{h1}, To: []Word{p1, p2}}, &Eq{From: h3, To: p3}, &Del{W: h2}),
+			categories: map[string]string{"HOM.1": CategoryCorrect, "HOM.2": CategoryCorrect, "HOM.3": CategoryCorrect, "PARA.1": CategoryCorrect, "PARA.2": CategoryCorrect, "PARA.3": CategoryCorrect},
+		},
+		{
+			gold:       NewFromEdits(&Sub{From: []Word{h1}, To: []Word{p1, p2}}, &Eq{From: h3, To: p3}, &Del{W: h2}),
+			predicted:  NewFromEdits(&Sub{From: []Word{h1}, To: []Word{p2}}, &Ins{W: p1}, &Sub{From: []Word{h2, h3}, To: []Word{p3}}),
+			categories: map[string]string{"HOM.1": CategoryShortSub, "HOM.2": CategoryMissedDel, "HOM.3": CategoryMissedEq, "PARA.1": CategorySpuriousIns, "PARA.2": CategoryShortSub, "PARA.3": CategoryMissedEq},
+		},
+		{
+			gold:       NewFromEdits(&Sub{From: []Word{h1}, To: []Word{p1}}, &Sub{From: []Word{h2}, To: []Word{p2}}, &Ins{W: p3}, &Del{W: h3}),
+			predicted:  NewFromEdits(&Sub{From: []Word{h1}, To: []Word{p1, p2}}, &Eq{From: h2, To: p3}),
+			categories: map[string]string{"HOM.1": CategoryLongSub, "HOM.2": CategorySpuriousEq, "HOM.3": CategoryUnaligned, "PARA.1": CategoryLongSub, "PARA.2": CategoryWrongSub, "PARA.3": CategorySpuriousEq},
+		},
+		{
+			gold:       NewFromEdits(&Eq{From: h3, To: p3}),
+			predicted:  NewFromEdits(&Eq{From: h3, To: p3}, &Del{W: h1}),
+			categories: map[string]string{"HOM.1": CategoryNotInGold, "HOM.3": CategoryCorrect, "PARA.3": CategoryCorrect},
+		},
+	}
+	for _, v := range tt {
+		diffs := DiffWords(v.gold, v.predicted)
+		if len(diffs) != len(v.categories) {
+			t.Error("Expected ", len(v.categories), " words got ", len(diffs))
+		}
+		prev := ""
+		for _, d := range diffs {
+			if c := d.Category(); c != v.categories[d.Word.ID] {
+				t.Error("Expected ", v.categories[d.Word.ID], " for ", d.Word.ID, " got ", c)
+			}
+			if prev != "" && !ProblemIDLess(prev, d.Word.ID) {
+				t.Error("Expected ", prev, " before ", d.Word.ID)
+			}
+			prev = d.Word.ID
+		}
+	}
+}
+
+func TestJSONEditToEdit(t *testing.T) {
+	db := DB{
+		"HOM.1":  Word{ID: "HOM.1", Text: "menin"},
+		"HOM.2":  Word{ID: "HOM.2", Text: "aeide"},
+		"PARA.1": Word{ID: "PARA.1", Text: "ten"},
+		"PARA.2": Word{ID: "PARA.2", Text: "orgen"},
+	}
+	tt := []struct {
+		edit     JSONEdit
+		expected string
+	}{
+		{edit: JSONEdit{Type: "ins", Target: []string{"PARA.1"}}, expected: "Ins"},
+		{edit: JSONEdit{Type: "del", Source: []string{"HOM.1"}}, expected: "Del"},
+		{edit: JSONEdit{Type: "eq", Source: []string{"HOM.1"}, Target: []string{"PARA.1"}}, expected: "Eq"},
+		{edit: JSONEdit{Type: "sub", Source: []string{"HOM.1", "HOM.2"}, Target: []string{"PARA.2"}}, expected: "Sub"},
+		{edit: JSONEdit{Type: "eq", Source: []string{"HOM.1", "HOM.2"}, Target: []string{"PARA.1"}}},
+		{edit: JSONEdit{Type: "sub", Source: []string{"HOM.9"}, Target: []string{"PARA.1"}}},
+	}
+	for _, v := range tt {
+		e, err := v.edit.ToEdit(db)
+		if v.expected == "" {
+			if err == nil {
+				t.Error("Expected error for ", v.edit)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if EditTypeName(e) != v.expected || e.(JSONEditer).ToJSONEdit().Type != v.edit.Type {
+			t.Error("Expected ", v.expected, " got ", e)
+		}
+	}
+}
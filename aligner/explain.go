@@ -0,0 +1,146 @@
+package aligner
+
+import (
+	"fmt"
+	"sort"
+)
+
+// FeatureContribution is the part of the score of one or more edits due to a feature
+type FeatureContribution struct {
+	Feature      string  `json:"feature"`
+	Value        float64 `json:"value"` // raw value of the feature, summed over the edits
+	Weight       float64 `json:"weight"`
+	Contribution float64 `json:"contribution"` // Weight * Value
+}
+
+// Breakdown is the score of a group of edits with the contribution of each feature
+type Breakdown struct {
+	Edits    []Edit                `json:"-"`
+	Score    float64               `json:"score"`
+	Features []FeatureContribution `json:"features"`
+}
+
+// Explanation tells why an edit was chosen: its score breakdown and the one of
+// the best rejected alternative aligning the same words, if any
+type Explanation struct {
+	Edit        Edit       `json:"-"`
+	Chosen      Breakdown  `json:"chosen"`
+	Alternative *Breakdown `json:"alternative,omitempty"`
+}
+
+// NewBreakdown computes the score of the edits with the named features and weights
+func NewBreakdown(edits []Edit, names []string, fs []Feature, ws []float64, ctx *Context) Breakdown {
+	b := Breakdown{Edits: edits, Features: make([]FeatureContribution, len(fs))}
+	for i, f := range fs {
+		c := FeatureContribution{Feature: names[i], Weight: ws[i]}
+		for _, e := range edits {
+			c.Value += f(e, ctx)
+		}
+		c.Contribution = c.Weight * c.Value
+		b.Features[i] = c
+		b.Score += c.Contribution
+	}
+	return b
+}
+
+// Explain returns the explanation of each edit of the alignment, following the
+// order of Edits, with the features and weights of the model
+func (a *Alignment) Explain(m *Model, ctx *Context) ([]Explanation, error) {
+	fs, ws, err := m.Resolve()
+	if err != nil {
+		return nil, err
+	}
+	res := []Explanation{}
+	for _, e := range a.Edits() {
+		x := Explanation{Edit: e, Chosen: NewBreakdown([]Edit{e}, m.Features, fs, ws, ctx)}
+		for _, alt := range alternatives(e, m.Params.SubseqLen) {
+			b := NewBreakdown(alt, m.Features, fs, ws, ctx)
+			if x.Alternative == nil || b.Score > x.Alternative.Score ||
+				(b.Score == x.Alternative.Score && editsKey(b.Edits) < editsKey(x.Alternative.Edits)) {
+				x.Alternative = &b
+			}
+		}
+		res = append(res, x)
+	}
+	return res, nil
+}
+
+// alternatives returns the other ways of aligning the words of e that the
+// aligners could produce: an Eq or a Sub of part of the words with the others
+// left as Del and Ins
+func alternatives(e Edit, subseqLen int) [][]Edit {
+	from, to := EditWords(e)
+	if len(from) == 0 || len(to) == 0 {
+		return nil
+	}
+	if subseqLen < 1 {
+		subseqLen = 1
+	}
+
+	res := [][]Edit{}
+	add := func(edits []Edit) {
+		if len(edits) == 1 && sameEdit(edits[0], e) {
+			return
+		}
+		res = append(res, edits)
+	}
+	// the subsets are limited to the span length of the aligners, the empty
+	// ones leave every word as Del and Ins
+	for _, fs := range subsets(from, subseqLen) {
+		for _, ts := range subsets(to, subseqLen) {
+			edits := []Edit{}
+			if len(fs) > 0 && len(ts) > 0 {
+				if len(fs) == 1 && len(ts) == 1 && SameText(fs[0], ts[0]) {
+					edits = append(edits, &Eq{From: fs[0], To: ts[0]})
+				} else {
+					edits = append(edits, &Sub{From: fs, To: ts})
+				}
+			} else if len(fs) > 0 || len(ts) > 0 {
+				continue
+			}
+			for _, w := range from {
+				if !containsWord(fs, w) {
+					edits = append(edits, &Del{W: w})
+				}
+			}
+			for _, w := range to {
+				if !containsWord(ts, w) {
+					edits = append(edits, &Ins{W: w})
+				}
+			}
+			add(edits)
+		}
+	}
+	return res
+}
+
+// subsets returns the empty set and the subsets of ws with at most max words
+func subsets(ws []Word, max int) [][]Word {
+	if max > len(ws) {
+		max = len(ws)
+	}
+	return append([][]Word{{}}, limitedSubsequences(ws, max)...)
+}
+
+func containsWord(ws []Word, w Word) bool {
+	for _, x := range ws {
+		if x.ID == w.ID {
+			return true
+		}
+	}
+	return false
+}
+
+func sameEdit(a, b Edit) bool {
+	return EditTypeName(a) == EditTypeName(b) && sameWords(a, b)
+}
+
+// editsKey identifies a group of edits independently of their order
+func editsKey(edits []Edit) string {
+	keys := []string{}
+	for _, e := range edits {
+		keys = append(keys, editKey(e))
+	}
+	sort.Strings(keys)
+	return fmt.Sprint(keys)
+}
@@ -0,0 +1,63 @@
+package aligner
+
+import (
+	"math"
+	"testing"
+)
+
+func TestExplain(t *testing.T) {
+	h1 := Word{ID: "HOM.1", Text: "thea", Source: "HOM"}
+	h2 := Word{ID: "HOM.2", Text: "aeide", Source: "HOM"}
+	p1 := Word{ID: "PARA.1", Text: "thea,", Source: "PARA"}
+	p2 := Word{ID: "PARA.2", Text: "eipe", Source: "PARA"}
+	p3 := Word{ID: "PARA.3", Text: "moi", Source: "PARA"}
+
+	a := NewFromEdits(&Eq{From: h1, To: p1}, &Sub{From: []Word{h2}, To: []Word{p2, p3}})
+	m := NewModel([]string{"TextualDistance", "EditType"}, []float64{0.7, 0.3}, LearnParams{SubseqLen: 2})
+	fs, ws, err := m.Resolve()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := NewContext()
+	xs, err := a.Explain(m, ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(xs) != 2 {
+		t.Fatal("Expected 2 explanations got ", len(xs))
+	}
+
+	tt := []struct {
+		alternatives int
+	}{
+		{1}, // Del HOM.1 + Ins PARA.1
+		{3}, // Sub or Eq with one PARA word, or Del and Ins of every word
+	}
+	for i, x := range xs {
+		if s := x.Edit.Score(fs, ws, ctx); math.Abs(s-x.Chosen.Score) > 1e-9 {
+			t.Error("Expected score ", s, " got ", x.Chosen.Score)
+		}
+		sum := 0.0
+		for _, f := range x.Chosen.Features {
+			sum += f.Contribution
+		}
+		if math.Abs(sum-x.Chosen.Score) > 1e-9 {
+			t.Error("Expected contributions summing to ", x.Chosen.Score, " got ", sum)
+		}
+		if n := len(alternatives(x.Edit, m.Params.SubseqLen)); n != tt[i].alternatives {
+			t.Error("Expected ", tt[i].alternatives, " alternatives got ", n)
+		}
+		if x.Alternative == nil {
+			t.Fatal("Expected an alternative for ", x.Edit)
+		}
+		for _, alt := range alternatives(x.Edit, m.Params.SubseqLen) {
+			if b := NewBreakdown(alt, m.Features, fs, ws, ctx); b.Score > x.Alternative.Score {
+				t.Error("Expected the best alternative ", x.Alternative.Score, " got ", b.Score)
+			}
+		}
+	}
+
+	if alts := alternatives(&Ins{W: p1}, 2); alts != nil {
+		t.Error("Expected no alternatives for Ins got ", alts)
+	}
+}
@@ -27,8 +27,7 @@ func (*greekAligner) next(a *Alignment, subSeqLen int) []Alignment {
 		x := x.(*Del)
 		for _, y := range inss {
 			y := y.(*Ins)
-			noPunctText := RemovePunctuation(x.W.Text)
-			if noPunctText != RemovePunctuation(y.W.Text) {
+			if !SameText(x.W, y.W) {
 				continue
 			}
 			eq := Eq{
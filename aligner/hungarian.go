@@ -0,0 +1,161 @@
+package aligner
+
+import (
+	"math"
+	"reflect"
+	"sort"
+)
+
+type hungarianAligner struct{}
+
+// NewHungarianAligner creates an aligner computing the best one-to-one
+// alignment of the words with the Hungarian algorithm
+func NewHungarianAligner() *hungarianAligner {
+	return &hungarianAligner{}
+}
+
+// next is not used since the matching is computed at once by search
+func (*hungarianAligner) next(a *Alignment, subSeqLen int) []Alignment {
+	return nil
+}
+
+func (*hungarianAligner) search(a *Alignment, fs []Feature, ws []float64, subseqLen int, ctx *Context) (*Alignment, error) {
+	dels, inss := openEdits(a)
+
+	delScores := make([]float64, len(dels))
+	for i, d := range dels {
+		delScores[i] = d.Score(fs, ws, ctx)
+	}
+	insScores := make([]float64, len(inss))
+	for j, x := range inss {
+		insScores[j] = x.Score(fs, ws, ctx)
+	}
+
+	// gain of pairing the words instead of leaving them as Del and Ins
+	pairs := make([][]Edit, len(dels))
+	gains := make([][]float64, len(dels))
+	for i, d := range dels {
+		pairs[i] = make([]Edit, len(inss))
+		gains[i] = make([]float64, len(inss))
+		for j, x := range inss {
+			pairs[i][j] = pairEdit(d.W, x.W)
+			gains[i][j] = pairs[i][j].Score(fs, ws, ctx) - delScores[i] - insScores[j]
+		}
+	}
+
+	res := a.clone()
+	for i, j := range maxWeightMatching(gains) {
+		if j < 0 {
+			continue
+		}
+		res.remove(dels[i], inss[j])
+		res.Add(pairs[i][j])
+	}
+	return &res, nil
+}
+
+// openEdits returns the Del and Ins edits of the alignment sorted by the
+// position of their word in the verse
+func openEdits(a *Alignment) ([]*Del, []*Ins) {
+	dels := []*Del{}
+	for _, e := range a.filter(reflect.TypeOf(&Del{})) {
+		dels = append(dels, e.(*Del))
+	}
+	inss := []*Ins{}
+	for _, e := range a.filter(reflect.TypeOf(&Ins{})) {
+		inss = append(inss, e.(*Ins))
+	}
+	sort.Slice(dels, func(x, y int) bool { return wordIndex(dels[x].W.ID) < wordIndex(dels[y].W.ID) })
+	sort.Slice(inss, func(x, y int) bool { return wordIndex(inss[x].W.ID) < wordIndex(inss[y].W.ID) })
+	return dels, inss
+}
+
+// pairEdit returns the edit aligning two words: an Eq when they have the same
+// text as in the greek aligner, a Sub otherwise
+func pairEdit(from, to Word) Edit {
+	if SameText(from, to) {
+		return &Eq{From: from, To: to}
+	}
+	return &Sub{From: []Word{from}, To: []Word{to}}
+}
+
+// maxWeightMatching returns for each row of w the column matched to it, or -1,
+// maximizing the sum of the weights of the matched cells. Cells with a non
+// positive weight are never matched.
+func maxWeightMatching(w [][]float64) []int {
+	n := len(w)
+	m := 0
+	if n > 0 {
+		m = len(w[0])
+	}
+	size := n
+	if m > size {
+		size = m
+	}
+	cost := func(i, j int) float64 {
+		if i < n && j < m && w[i][j] > 0 {
+			return -w[i][j]
+		}
+		return 0
+	}
+
+	// Kuhn-Munkres with potentials on the square matrix, rows and columns are 1-based
+	u := make([]float64, size+1)
+	v := make([]float64, size+1)
+	p := make([]int, size+1) // row matched to each column
+	way := make([]int, size+1)
+	for i := 1; i <= size; i++ {
+		p[0] = i
+		j0 := 0
+		minv := make([]float64, size+1)
+		for j := range minv {
+			minv[j] = math.Inf(1)
+		}
+		used := make([]bool, size+1)
+		for p[j0] != 0 {
+			used[j0] = true
+			i0 := p[j0]
+			delta := math.Inf(1)
+			j1 := 0
+			for j := 1; j <= size; j++ {
+				if used[j] {
+					continue
+				}
+				if cur := cost(i0-1, j-1) - u[i0] - v[j]; cur < minv[j] {
+					minv[j] = cur
+					way[j] = j0
+				}
+				if minv[j] < delta {
+					delta = minv[j]
+					j1 = j
+				}
+			}
+			for j := 0; j <= size; j++ {
+				if used[j] {
+					u[p[j]] += delta
+					v[j] -= delta
+				} else {
+					minv[j] -= delta
+				}
+			}
+			j0 = j1
+		}
+		for j0 != 0 {
+			j1 := way[j0]
+			p[j0] = p[j1]
+			j0 = j1
+		}
+	}
+
+	match := make([]int, n)
+	for i := range match {
+		match[i] = -1
+	}
+	for j := 1; j <= size; j++ {
+		i := p[j] - 1
+		if i < n && j-1 < m && w[i][j-1] > 0 {
+			match[i] = j - 1
+		}
+	}
+	return match
+}
@@ -0,0 +1,87 @@
+package aligner
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+func TestMaxWeightMatching(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for k := 0; k < 50; k++ {
+		n, m := 1+r.Intn(5), 1+r.Intn(5)
+		w := make([][]float64, n)
+		for i := range w {
+			w[i] = make([]float64, m)
+			for j := range w[i] {
+				w[i][j] = r.Float64()*2 - 0.5
+			}
+		}
+		match := maxWeightMatching(w)
+		got := 0.0
+		used := map[int]bool{}
+		for i, j := range match {
+			if j < 0 {
+				continue
+			}
+			if used[j] {
+				t.Fatal("Expected one-to-one matching got ", match)
+			}
+			used[j] = true
+			got += w[i][j]
+		}
+		if expected := bruteForceMatching(w, 0, map[int]bool{}); got < expected-1e-9 {
+			t.Error("Expected ", expected, " got ", got, " for ", w)
+		}
+	}
+}
+
+func bruteForceMatching(w [][]float64, i int, used map[int]bool) float64 {
+	if i == len(w) {
+		return 0
+	}
+	best := bruteForceMatching(w, i+1, used)
+	for j := range w[i] {
+		if used[j] {
+			continue
+		}
+		used[j] = true
+		if s := w[i][j] + bruteForceMatching(w, i+1, used); s > best {
+			best = s
+		}
+		used[j] = false
+	}
+	return best
+}
+
+func TestHungarianAligner(t *testing.T) {
+	from := WordsBag{}
+	to := WordsBag{}
+	for i, text := range []string{"menin", "aeide", "thea", "peleiadeo"} {
+		w := Word{ID: GetWordID("HOM", fmt.Sprint(i+1)), Text: text, Source: "HOM"}
+		from[w.ID] = w
+	}
+	for i, text := range []string{"ten", "orgen", "eipe", "thea,", "tou", "peleidou"} {
+		w := Word{ID: GetWordID("PARA", fmt.Sprint(i+1)), Text: text, Source: "PARA"}
+		to[w.ID] = w
+	}
+	fs := []Feature{LexicalSimilarity, EditType}
+	ws := []float64{1.0, 0.1}
+
+	ctx := NewContext()
+	greedy, err := NewFromWordBags(from, to).Align(NewGreekAligner(), fs, ws, 1, ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := NewFromWordBags(from, to).Align(NewHungarianAligner(), fs, ws, 1, ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s, g := a.Score(fs, ws, ctx), greedy.Score(fs, ws, ctx); s < g {
+		t.Error("Expected score >= ", g, " got ", s)
+	}
+	eq := &Eq{From: from["HOM.3"], To: to["PARA.4"]}
+	if !a.includes(eq) {
+		t.Error("Expected ", eq, " in ", a)
+	}
+}
@@ -0,0 +1,199 @@
+package aligner
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+)
+
+// ibmNull is the source token generating the PARA words aligned to no HOM word
+const ibmNull = "<null>"
+
+// IBMModel1 holds the lexical translation probabilities t(para|hom) of IBM Model 1
+// between the values of a field of the HOM and PARA words, Lemma or Text
+type IBMModel1 struct {
+	Field      string                        `json:"field"`
+	Iterations int                           `json:"iterations"`
+	T          map[string]map[string]float64 `json:"t"` // by HOM and then PARA token
+}
+
+// TrainIBMModel1 estimates the model with the given number of EM iterations on
+// the verse pairs of the DB, that need not be aligned
+func TrainIBMModel1(db DB, field string, iterations int) (*IBMModel1, error) {
+	if field != "Lemma" && field != "Text" {
+		return nil, fmt.Errorf("IBM Model 1 field must be Lemma or Text, got %q", field)
+	}
+	problems := ProblemsFromDB(db)
+	ids := []string{}
+	for id := range problems {
+		ids = append(ids, id)
+	}
+	SortProblemIDs(ids)
+
+	// the pairs are visited in a fixed order so that the same DB gives the same model
+	pairs := [][2][]string{}
+	for _, id := range ids {
+		p := problems[id]
+		from := append([]string{ibmNull}, ibmTokens(p.From, field)...)
+		to := ibmTokens(p.To, field)
+		if len(to) > 0 {
+			pairs = append(pairs, [2][]string{from, to})
+		}
+	}
+
+	m := &IBMModel1{Field: field, Iterations: iterations, T: map[string]map[string]float64{}}
+	vocabulary := map[string]bool{}
+	for _, p := range pairs {
+		for _, f := range p[1] {
+			vocabulary[f] = true
+		}
+	}
+	for _, p := range pairs {
+		for _, e := range p[0] {
+			if m.T[e] == nil {
+				m.T[e] = map[string]float64{}
+			}
+			for _, f := range p[1] {
+				m.T[e][f] = 1 / float64(len(vocabulary))
+			}
+		}
+	}
+
+	for k := 0; k < iterations; k++ {
+		counts := map[string]map[string]float64{}
+		totals := map[string]float64{}
+		for _, p := range pairs {
+			for _, f := range p[1] {
+				z := 0.0
+				for _, e := range p[0] {
+					z += m.T[e][f]
+				}
+				for _, e := range p[0] {
+					c := m.T[e][f] / z
+					if counts[e] == nil {
+						counts[e] = map[string]float64{}
+					}
+					counts[e][f] += c
+					totals[e] += c
+				}
+			}
+		}
+		for e, fs := range counts {
+			for f, c := range fs {
+				m.T[e][f] = c / totals[e]
+			}
+		}
+	}
+	return m, nil
+}
+
+// ibmTokens returns the values of the field of the words in text order
+func ibmTokens(ws WordsBag, field string) []string {
+	ids := []string{}
+	for id := range ws {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return wordIndex(ids[i]) < wordIndex(ids[j]) })
+	res := []string{}
+	for _, id := range ids {
+		if t := ibmToken(ws[id], field); t != "" {
+			res = append(res, t)
+		}
+	}
+	return res
+}
+
+func ibmToken(w Word, field string) string {
+	if field == "Lemma" {
+		return w.Lemma
+	}
+	return RemovePunctuation(w.Text)
+}
+
+// Prob returns t(para|hom), the probability that the HOM token is translated by
+// the PARA token, 0 for unseen pairs
+func (m *IBMModel1) Prob(hom, para string) float64 {
+	return m.T[hom][para]
+}
+
+// SaveIBMModels writes the models to a JSON file
+func SaveIBMModels(path string, models []*IBMModel1) error {
+	d, err := json.Marshal(models)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, d, 0644)
+}
+
+// LoadIBMModels reads the models written by SaveIBMModels
+func LoadIBMModels(path string) ([]*IBMModel1, error) {
+	d, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	models := []*IBMModel1{}
+	if err := json.Unmarshal(d, &models); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return models, nil
+}
+
+// LoadIBMModels reads the models written by SaveIBMModels and stores each of them
+// in the context as the IBMModel1 resource of its field, IBMModel1Lemma or IBMModel1Text
+func (c *Context) LoadIBMModels(path string) ([]*IBMModel1, error) {
+	models, err := LoadIBMModels(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range models {
+		c.Set("IBMModel1"+m.Field, m)
+	}
+	return models, nil
+}
+
+// IBM1Lemma scores the edit with the IBM Model 1 probabilities of the lemmas
+func IBM1Lemma(e Edit, ctx *Context) float64 {
+	return ibm1Score(e, ctx, "IBM1Lemma", "Lemma")
+}
+
+// IBM1Text scores the edit with the IBM Model 1 probabilities of the texts
+func IBM1Text(e Edit, ctx *Context) float64 {
+	return ibm1Score(e, ctx, "IBM1Text", "Text")
+}
+
+// ibm1Score is the mean over the PARA words of the edit of their best translation
+// probability from one of its HOM words. An Ins gets the probability of the PARA
+// word being generated by no HOM word and a Del gets 0.
+func ibm1Score(e Edit, ctx *Context, funcName, field string) float64 {
+	if v, ok := ctx.cachedScore(funcName, e); ok {
+		return v
+	}
+	r, _ := ctx.Resource("IBMModel1" + field)
+	m := r.(*IBMModel1)
+
+	from, to := getWords(e)
+	homs := []string{}
+	for _, w := range from {
+		homs = append(homs, ibmToken(w, field))
+	}
+	if len(homs) == 0 {
+		homs = []string{ibmNull}
+	}
+	res := 0.0
+	for _, w := range to {
+		f := ibmToken(w, field)
+		best := 0.0
+		for _, h := range homs {
+			if p := m.Prob(h, f); p > best {
+				best = p
+			}
+		}
+		res += best
+	}
+	if len(to) > 0 {
+		res /= float64(len(to))
+	}
+	ctx.cacheScore(funcName, e, res)
+	return res
+}
@@ -0,0 +1,89 @@
+package aligner
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestIBMModel1(t *testing.T) {
+	db := DB{}
+	words := map[string]Word{}
+	id := 0
+	for verse, texts := range [][2][]string{
+		{{"menin", "aeide"}, {"orgen", "eipe"}},
+		{{"menin", "thea"}, {"orgen", "thea"}},
+		{{"aeide", "thea"}, {"eipe", "thea"}},
+	} {
+		for k, source := range []string{"HOM", "PARA"} {
+			for _, text := range texts[k] {
+				id++
+				w := Word{ID: GetWordID(source, fmt.Sprint(id)), Text: text, Lemma: text, Source: source, Chant: "1", Verse: fmt.Sprint(verse + 1)}
+				db[w.ID] = w
+				words[source+text] = w
+			}
+		}
+	}
+
+	m, err := TrainIBMModel1(db, "Lemma", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tt := []struct {
+		hom, para, other string
+	}{
+		{"menin", "orgen", "eipe"},
+		{"aeide", "eipe", "orgen"},
+		{"thea", "thea", "orgen"},
+	}
+	for _, x := range tt {
+		if p, q := m.Prob(x.hom, x.para), m.Prob(x.hom, x.other); p <= q {
+			t.Error("Expected t(", x.para, "|", x.hom, ") > t(", x.other, "|", x.hom, ") got ", p, " and ", q)
+		}
+	}
+	for hom, ps := range m.T {
+		sum := 0.0
+		for _, p := range ps {
+			sum += p
+		}
+		if math.Abs(sum-1) > 1e-9 {
+			t.Error("Expected probabilities of ", hom, " summing to 1 got ", sum)
+		}
+	}
+	if _, err := TrainIBMModel1(db, "Tag", 1); err == nil {
+		t.Error("Expected an error for the Tag field")
+	}
+
+	dir, err := ioutil.TempDir("", "ibm1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "ibm1.json")
+	if err := SaveIBMModels(path, []*IBMModel1{m}); err != nil {
+		t.Fatal(err)
+	}
+	ctx := NewContext()
+	loaded, err := ctx.LoadIBMModels(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded) != 1 || !reflect.DeepEqual(loaded[0], m) {
+		t.Error("Expected ", m, " got ", loaded)
+	}
+
+	eq := &Sub{From: []Word{words["HOMmenin"]}, To: []Word{words["PARAorgen"]}}
+	if v := IBM1Lemma(eq, ctx); v != m.Prob("menin", "orgen") {
+		t.Error("Expected ", m.Prob("menin", "orgen"), " got ", v)
+	}
+	if v := IBM1Lemma(&Ins{W: words["PARAthea"]}, ctx); v != m.Prob(ibmNull, "thea") {
+		t.Error("Expected ", m.Prob(ibmNull, "thea"), " got ", v)
+	}
+	if v := IBM1Lemma(&Del{W: words["HOMthea"]}, ctx); v != 0 {
+		t.Error("Expected 0 got ", v)
+	}
+}
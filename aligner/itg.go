@@ -0,0 +1,145 @@
+package aligner
+
+import (
+	"log"
+	"math"
+)
+
+// itgMaxSpans bounds the chart of the ITG aligner, that holds a derivation for
+// each pair of HOM and PARA spans: (n+1)²(m+1)² for n HOM and m PARA words,
+// searched in O(n³m³). About 2M spans take 100MB and a couple of seconds, as
+// for 30 HOM and 45 PARA words. Longer verses are aligned by the monotonic aligner.
+var itgMaxSpans = 1 << 21
+
+type itgAligner struct{}
+
+// NewITGAligner creates an aligner based on a bracketing inversion transduction
+// grammar: the verses are split in blocks aligned in the same or inverted order
+func NewITGAligner() *itgAligner {
+	return &itgAligner{}
+}
+
+// next is not used since the alignment is computed at once by search
+func (*itgAligner) next(a *Alignment, subSeqLen int) []Alignment {
+	return nil
+}
+
+// itgSpan is the best derivation of a pair of spans of the HOM and PARA words
+type itgSpan struct {
+	score    float64
+	edit     Edit // set for the spans aligned by a single edit
+	k, l     int  // split points of the HOM and PARA spans
+	inverted bool
+	all      bool // the span contains only Del or only Ins edits
+}
+
+// search aligns the Del and Ins words of a with the best bracketing
+// derivation: the edits are the Eq and Sub of a word with up to subseqLen
+// consecutive words of the other text, Del and Ins, combined in straight or
+// inverted order. Verses with more spans than itgMaxSpans are aligned by the
+// monotonic aligner instead.
+func (*itgAligner) search(a *Alignment, fs []Feature, ws []float64, subseqLen int, ctx *Context) (*Alignment, error) {
+	dels, inss := openEdits(a)
+	n, m := len(dels), len(inss)
+	from := make([]Word, n)
+	for i, d := range dels {
+		from[i] = d.W
+	}
+	to := make([]Word, m)
+	for j, x := range inss {
+		to[j] = x.W
+	}
+	if spans := (n + 1) * (n + 1) * (m + 1) * (m + 1); spans > itgMaxSpans {
+		log.Printf("itg: %d HOM and %d PARA words need %d spans, more than %d, falling back to the monotonic aligner", n, m, spans, itgMaxSpans)
+		return NewMonotonicAligner().search(a, fs, ws, subseqLen, ctx)
+	}
+	if subseqLen < 1 {
+		subseqLen = 1
+	}
+
+	delScores := make([]float64, n)
+	for i, d := range dels {
+		delScores[i] = d.Score(fs, ws, ctx)
+	}
+	insScores := make([]float64, m)
+	for j, x := range inss {
+		insScores[j] = x.Score(fs, ws, ctx)
+	}
+
+	spans := make([]itgSpan, (n+1)*(n+1)*(m+1)*(m+1))
+	at := func(i1, i2, j1, j2 int) *itgSpan {
+		return &spans[((i1*(n+1)+i2)*(m+1)+j1)*(m+1)+j2]
+	}
+
+	for total := 1; total <= n+m; total++ {
+		for hl := 0; hl <= n && hl <= total; hl++ {
+			pl := total - hl
+			if pl > m {
+				continue
+			}
+			for i1 := 0; i1+hl <= n; i1++ {
+				for j1 := 0; j1+pl <= m; j1++ {
+					i2, j2 := i1+hl, j1+pl
+					s := at(i1, i2, j1, j2)
+					s.score = math.Inf(-1)
+
+					switch {
+					case pl == 0:
+						s.score, s.all = 0, true
+						for i := i1; i < i2; i++ {
+							s.score += delScores[i]
+						}
+						continue
+					case hl == 0:
+						s.score, s.all = 0, true
+						for j := j1; j < j2; j++ {
+							s.score += insScores[j]
+						}
+						continue
+					case hl == 1 && pl == 1:
+						s.edit = pairEdit(from[i1], to[j1])
+						s.score = s.edit.Score(fs, ws, ctx)
+					case (hl == 1 && pl <= subseqLen) || (pl == 1 && hl <= subseqLen):
+						s.edit = &Sub{From: copyWords(from[i1:i2]), To: copyWords(to[j1:j2])}
+						s.score = s.edit.Score(fs, ws, ctx)
+					}
+
+					for k := i1; k <= i2; k++ {
+						for l := j1; l <= j2; l++ {
+							if !(k == i1 && l == j1) && !(k == i2 && l == j2) {
+								if v := at(i1, k, j1, l).score + at(k, i2, l, j2).score; v > s.score {
+									*s = itgSpan{score: v, k: k, l: l}
+								}
+							}
+							if !(k == i1 && l == j2) && !(k == i2 && l == j1) {
+								if v := at(i1, k, l, j2).score + at(k, i2, j1, l).score; v > s.score {
+									*s = itgSpan{score: v, k: k, l: l, inverted: true}
+								}
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+
+	res := a.clone()
+	var collect func(i1, i2, j1, j2 int)
+	collect = func(i1, i2, j1, j2 int) {
+		s := at(i1, i2, j1, j2)
+		switch {
+		case s.all || (i1 == i2 && j1 == j2):
+		case s.edit != nil:
+			removeEditWithWordsByID(&res, append(copyWords(from[i1:i2]), to[j1:j2]...)...)
+			res.Add(s.edit)
+		case s.inverted:
+			collect(i1, s.k, s.l, j2)
+			collect(s.k, i2, j1, s.l)
+		default:
+			collect(i1, s.k, j1, s.l)
+			collect(s.k, i2, s.l, j2)
+		}
+	}
+	collect(0, n, 0, m)
+	return &res, nil
+}
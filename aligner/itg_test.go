@@ -0,0 +1,84 @@
+package aligner
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestITGAligner(t *testing.T) {
+	tt := []struct {
+		from, to  []string
+		subseqLen int
+		eqs       int
+	}{
+		{from: []string{"achilleos", "peleiadeo"}, to: []string{"peleiadeo", "achilleos"}, subseqLen: 1, eqs: 2},
+		{from: []string{"menin", "aeide", "thea", "peleiadeo"}, to: []string{"ten", "orgen", "eipe", "thea", "tou", "peleidou"}, subseqLen: 2, eqs: 1},
+		{from: []string{"peleiadeo", "achileos", "menin"}, to: []string{"orgen", "tou", "achilleos", "peleidou"}, subseqLen: 2, eqs: 0},
+	}
+	fs := []Feature{LexicalSimilarity, EditType}
+	ws := []float64{1.0, 0.1}
+	for _, v := range tt {
+		from := make([]Word, len(v.from))
+		for i, text := range v.from {
+			from[i] = Word{ID: GetWordID("HOM", fmt.Sprint(i+1)), Text: text, Source: "HOM"}
+		}
+		to := make([]Word, len(v.to))
+		for i, text := range v.to {
+			to[i] = Word{ID: GetWordID("PARA", fmt.Sprint(i+1)), Text: text, Source: "PARA"}
+		}
+
+		ctx := NewContext()
+		a, err := new(from, to).Align(NewITGAligner(), fs, ws, v.subseqLen, ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		monotonic, err := new(from, to).Align(NewMonotonicAligner(), fs, ws, v.subseqLen, ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s, m := a.Score(fs, ws, ctx), monotonic.Score(fs, ws, ctx); s < m-1e-9 {
+			t.Error("Expected score >= ", m, " got ", s, " for ", a)
+		}
+		if eqs := len(a.filter(reflect.TypeOf(&Eq{}))); eqs != v.eqs {
+			t.Error("Expected ", v.eqs, " Eq got ", eqs, " in ", a)
+		}
+		words := 0
+		for _, e := range a.Edits() {
+			from, to := getWords(e)
+			words += len(from) + len(to)
+		}
+		if words != len(v.from)+len(v.to) {
+			t.Error("Expected ", len(v.from)+len(v.to), " aligned words got ", words, " in ", a)
+		}
+	}
+}
+
+func TestITGAlignerFallback(t *testing.T) {
+	defer func(max int) { itgMaxSpans = max }(itgMaxSpans)
+	itgMaxSpans = 100
+
+	from := make([]Word, 4)
+	for i := range from {
+		from[i] = Word{ID: GetWordID("HOM", fmt.Sprint(i+1)), Text: fmt.Sprint("w", i), Source: "HOM"}
+	}
+	to := make([]Word, 5)
+	for i := range to {
+		to[i] = Word{ID: GetWordID("PARA", fmt.Sprint(i+1)), Text: fmt.Sprint("w", 4-i), Source: "PARA"}
+	}
+	fs := []Feature{LexicalSimilarity, EditType}
+	ws := []float64{1.0, 0.1}
+	ctx := NewContext()
+
+	a, err := new(from, to).Align(NewITGAligner(), fs, ws, 2, ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	monotonic, err := new(from, to).Align(NewMonotonicAligner(), fs, ws, 2, ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.key() != monotonic.key() {
+		t.Error("Expected the monotonic alignment ", monotonic, " got ", a)
+	}
+}
@@ -0,0 +1,77 @@
+package aligner
+
+// Link connects a HOM word to a PARA word by their IDs
+type Link struct {
+	From, To string
+}
+
+// Links returns the word links of the alignment: an Eq is a single link, a Sub
+// links each of its From words to each of its To words, Ins and Del have no link
+func (a *Alignment) Links() map[Link]bool {
+	links := map[Link]bool{}
+	for _, e := range a.editMap {
+		from, to := getWords(e)
+		for _, f := range from {
+			for _, t := range to {
+				links[Link{From: f.ID, To: t.ID}] = true
+			}
+		}
+	}
+	return links
+}
+
+// LinkCounts holds the number of links of a predicted and a gold alignment and
+// of the predicted links that are also gold links
+type LinkCounts struct {
+	Predicted int `json:"predicted"`
+	Gold      int `json:"gold"`
+	Correct   int `json:"correct"`
+}
+
+// CompareLinks counts the links of the predicted alignment found in the gold one
+func CompareLinks(gold, predicted *Alignment) LinkCounts {
+	g, p := gold.Links(), predicted.Links()
+	c := LinkCounts{Predicted: len(p), Gold: len(g)}
+	for l := range p {
+		if g[l] {
+			c.Correct++
+		}
+	}
+	return c
+}
+
+// Add sums the counts, so that the metrics can be computed over several verses
+func (c LinkCounts) Add(o LinkCounts) LinkCounts {
+	return LinkCounts{Predicted: c.Predicted + o.Predicted, Gold: c.Gold + o.Gold, Correct: c.Correct + o.Correct}
+}
+
+// Precision is the ratio of predicted links that are correct, 1 without predicted links
+func (c LinkCounts) Precision() float64 {
+	if c.Predicted == 0 {
+		return 1
+	}
+	return float64(c.Correct) / float64(c.Predicted)
+}
+
+// Recall is the ratio of gold links that are predicted, 1 without gold links
+func (c LinkCounts) Recall() float64 {
+	if c.Gold == 0 {
+		return 1
+	}
+	return float64(c.Correct) / float64(c.Gold)
+}
+
+// F1 is the harmonic mean of precision and recall
+func (c LinkCounts) F1() float64 {
+	return 1 - c.AER()
+}
+
+// AER is the Alignment Error Rate of Och and Ney. The gold standard does not
+// distinguish sure and possible links, so every gold link is sure and the AER
+// is 1 - F1.
+func (c LinkCounts) AER() float64 {
+	if c.Predicted+c.Gold == 0 {
+		return 0
+	}
+	return 1 - 2*float64(c.Correct)/float64(c.Predicted+c.Gold)
+}
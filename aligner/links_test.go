@@ -0,0 +1,66 @@
+package aligner
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCompareLinks(t *testing.T) {
+	h1 := Word{ID: "HOM.1", Text: "menin"}
+	h2 := Word{ID: "HOM.2", Text: "aeide"}
+	h3 := Word{ID: "HOM.3", Text: "thea"}
+	p1 := Word{ID: "PARA.1", Text: "ten"}
+	p2 := Word{ID: "PARA.2", Text: "orgen"}
+	p3 := Word{ID: "PARA.3", Text: "eipe"}
+	p4 := Word{ID: "PARA.4", Text: "thea"}
+
+	gold := NewFromEdits(&Sub{From: []Word{h1}, To: []Word{p1, p2}}, &Sub{From: []Word{h2}, To: []Word{p3}}, &Eq{From: h3, To: p4})
+
+	tt := []struct {
+		predicted                  *Alignment
+		counts                     LinkCounts
+		precision, recall, f1, aer float64
+	}{
+		{
+			predicted: gold,
+			counts:    LinkCounts{Predicted: 4, Gold: 4, Correct: 4},
+			precision: 1, recall: 1, f1: 1, aer: 0,
+		},
+		{
+			predicted: NewFromEdits(&Sub{From: []Word{h1}, To: []Word{p2}}, &Del{W: h2}, &Ins{W: p1}, &Ins{W: p3}, &Eq{From: h3, To: p4}),
+			counts:    LinkCounts{Predicted: 2, Gold: 4, Correct: 2},
+			precision: 1, recall: 0.5, f1: 2.0 / 3, aer: 1.0 / 3,
+		},
+		{
+			predicted: NewFromEdits(&Sub{From: []Word{h1, h2}, To: []Word{p1, p2, p3}}, &Eq{From: h3, To: p4}),
+			counts:    LinkCounts{Predicted: 7, Gold: 4, Correct: 4},
+			precision: 4.0 / 7, recall: 1, f1: 8.0 / 11, aer: 3.0 / 11,
+		},
+		{
+			predicted: new([]Word{h1, h2, h3}, []Word{p1, p2, p3, p4}),
+			counts:    LinkCounts{Predicted: 0, Gold: 4, Correct: 0},
+			precision: 1, recall: 0, f1: 0, aer: 1,
+		},
+	}
+	for _, v := range tt {
+		c := CompareLinks(gold, v.predicted)
+		if c != v.counts {
+			t.Error("Expected ", v.counts, " got ", c)
+		}
+		for _, m := range []struct{ expected, got float64 }{
+			{v.precision, c.Precision()},
+			{v.recall, c.Recall()},
+			{v.f1, c.F1()},
+			{v.aer, c.AER()},
+		} {
+			if math.Abs(m.expected-m.got) > 1e-9 {
+				t.Error("Expected ", m.expected, " got ", m.got, " for ", c)
+			}
+		}
+	}
+
+	total := LinkCounts{Predicted: 2, Gold: 4, Correct: 2}.Add(LinkCounts{Predicted: 7, Gold: 4, Correct: 4})
+	if expected := (LinkCounts{Predicted: 9, Gold: 8, Correct: 6}); total != expected {
+		t.Error("Expected ", expected, " got ", total)
+	}
+}
@@ -0,0 +1,95 @@
+package aligner
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+)
+
+// ModelVersion is the version of the model file format written by Save
+const ModelVersion = 1
+
+// LearnParams are the perceptron hyperparameters used to train a model
+type LearnParams struct {
+	N         int     `json:"N"`
+	N0        int     `json:"N0"`
+	R0        float64 `json:"R0"`
+	R         float64 `json:"r"`
+	SubseqLen int     `json:"subseqLen"`
+	Seed      int64   `json:"seed"` // seed of the random choices of the training
+}
+
+// Input describes a data file used to train a model
+type Input struct {
+	Path   string `json:"path"`
+	SHA256 string `json:"sha256"`
+}
+
+// Model stores the learned weights together with the features they refer to
+type Model struct {
+	Version  int              `json:"version"`
+	Features []string         `json:"features"`
+	Weights  []float64        `json:"weights"`
+	Params   LearnParams      `json:"params"`
+	Inputs   map[string]Input `json:"inputs,omitempty"`
+	// Association holds the lemma association learned from the training set,
+	// when needed by the features
+	Association *LemmaAssociation `json:"association,omitempty"`
+}
+
+// NewModel creates a model for the given feature names and weights
+func NewModel(features []string, w []float64, params LearnParams) *Model {
+	return &Model{
+		Version:  ModelVersion,
+		Features: features,
+		Weights:  w,
+		Params:   params,
+		Inputs:   map[string]Input{},
+	}
+}
+
+// Resolve returns the features and weights of the model
+func (m *Model) Resolve() ([]Feature, []float64, error) {
+	if len(m.Features) != len(m.Weights) {
+		return nil, nil, fmt.Errorf("model has %d features and %d weights", len(m.Features), len(m.Weights))
+	}
+	fs := make([]Feature, len(m.Features))
+	for i, name := range m.Features {
+		f, err := FeatureByName(name)
+		if err != nil {
+			return nil, nil, err
+		}
+		fs[i] = f
+	}
+	ws := make([]float64, len(m.Weights))
+	copy(ws, m.Weights)
+	return fs, ws, nil
+}
+
+// Save writes the model as JSON to path
+func (m *Model) Save(path string) error {
+	d, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, d, 0644)
+}
+
+// LoadModel reads a model previously written by Save
+func LoadModel(path string) (*Model, error) {
+	d, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var m Model
+	if err := json.Unmarshal(d, &m); err != nil {
+		return nil, err
+	}
+	if m.Version != ModelVersion {
+		return nil, fmt.Errorf("unsupported model version %d in %s", m.Version, path)
+	}
+	if _, _, err := m.Resolve(); err != nil {
+		return nil, fmt.Errorf("invalid model %s: %v", path, err)
+	}
+	return &m, nil
+}
@@ -0,0 +1,64 @@
+package aligner
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestModelSaveLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "model")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "model.json")
+
+	m := NewModel([]string{"TextualDistance", "TagDistance"}, []float64{0.7, 0.3}, LearnParams{N: 50, N0: 10, R0: 1.0, R: 0.8, SubseqLen: 1})
+	m.Inputs["words"] = Input{Path: "words.xlsx", SHA256: "abc"}
+	if err := m.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadModel(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fs, ws, err := loaded.Resolve()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fs) != 2 || ws[0] != 0.7 || ws[1] != 0.3 {
+		t.Error("Expected 2 features with weights [0.7 0.3] got ", len(fs), " ", ws)
+	}
+	if loaded.Params != m.Params || loaded.Inputs["words"] != m.Inputs["words"] {
+		t.Error("Expected ", m, " got ", loaded)
+	}
+}
+
+func TestModelLoadErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "model")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tt := []struct {
+		name string
+		data string
+	}{
+		{name: "version", data: `{"version": 99, "features": [], "weights": []}`},
+		{name: "feature", data: `{"version": 1, "features": ["Unknown"], "weights": [1]}`},
+		{name: "weights", data: `{"version": 1, "features": ["TagDistance"], "weights": [1, 2]}`},
+	}
+	for _, v := range tt {
+		path := filepath.Join(dir, v.name+".json")
+		if err := ioutil.WriteFile(path, []byte(v.data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadModel(path); err == nil {
+			t.Error("Expected error loading model with invalid ", v.name)
+		}
+	}
+}
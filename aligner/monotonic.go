@@ -0,0 +1,97 @@
+package aligner
+
+import "math"
+
+type monotonicAligner struct{}
+
+// NewMonotonicAligner creates an aligner following the order of the words in
+// both texts, computing the best alignment by dynamic programming
+func NewMonotonicAligner() *monotonicAligner {
+	return &monotonicAligner{}
+}
+
+// next is not used since the alignment is computed at once by search
+func (*monotonicAligner) next(a *Alignment, subSeqLen int) []Alignment {
+	return nil
+}
+
+// search aligns the Del and Ins words of a in order with Eq, Ins, Del and Sub
+// edits, where a Sub aligns a word with up to subseqLen consecutive words
+func (*monotonicAligner) search(a *Alignment, fs []Feature, ws []float64, subseqLen int, ctx *Context) (*Alignment, error) {
+	dels, inss := openEdits(a)
+	from := make([]Word, len(dels))
+	for i, d := range dels {
+		from[i] = d.W
+	}
+	to := make([]Word, len(inss))
+	for j, x := range inss {
+		to[j] = x.W
+	}
+	if subseqLen < 1 {
+		subseqLen = 1
+	}
+
+	// best[i][j] is the score of the best alignment of from[:i] and to[:j],
+	// reached from best[i-di][j-dj] with the edit in last[i][j]
+	type step struct {
+		di, dj int
+		edit   Edit
+	}
+	best := make([][]float64, len(from)+1)
+	last := make([][]step, len(from)+1)
+	for i := range best {
+		best[i] = make([]float64, len(to)+1)
+		last[i] = make([]step, len(to)+1)
+		for j := range best[i] {
+			best[i][j] = math.Inf(-1)
+		}
+	}
+	best[0][0] = 0
+
+	try := func(i, j int, s step) {
+		if v := best[i-s.di][j-s.dj] + s.edit.Score(fs, ws, ctx); v > best[i][j] {
+			best[i][j] = v
+			last[i][j] = s
+		}
+	}
+	for i := 0; i <= len(from); i++ {
+		for j := 0; j <= len(to); j++ {
+			if i > 0 {
+				try(i, j, step{di: 1, edit: dels[i-1]})
+			}
+			if j > 0 {
+				try(i, j, step{dj: 1, edit: inss[j-1]})
+			}
+			if i > 0 && j > 0 {
+				try(i, j, step{di: 1, dj: 1, edit: pairEdit(from[i-1], to[j-1])})
+			}
+			for n := 2; n <= subseqLen; n++ {
+				if i > 0 && j >= n {
+					try(i, j, step{di: 1, dj: n, edit: &Sub{From: copyWords(from[i-1 : i]), To: copyWords(to[j-n : j])}})
+				}
+				if j > 0 && i >= n {
+					try(i, j, step{di: n, dj: 1, edit: &Sub{From: copyWords(from[i-n : i]), To: copyWords(to[j-1 : j])}})
+				}
+			}
+		}
+	}
+
+	res := a.clone()
+	for i, j := len(from), len(to); i > 0 || j > 0; {
+		s := last[i][j]
+		switch s.edit.(type) {
+		case *Del, *Ins:
+		default:
+			removeEditWithWordsByID(&res, append(copyWords(from[i-s.di:i]), to[j-s.dj:j]...)...)
+			res.Add(s.edit)
+		}
+		i -= s.di
+		j -= s.dj
+	}
+	return &res, nil
+}
+
+// copyWords returns a copy of ws not sharing its backing array
+func copyWords(ws []Word) []Word {
+	return append([]Word{}, ws...)
+}
@@ -0,0 +1,72 @@
+package aligner
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestMonotonicAligner(t *testing.T) {
+	tt := []struct {
+		from, to  []string
+		subseqLen int
+	}{
+		{from: []string{"menin", "aeide", "thea"}, to: []string{"menin", "aeide", "thea"}, subseqLen: 1},
+		{from: []string{"menin", "aeide", "thea", "peleiadeo"}, to: []string{"ten", "orgen", "eipe", "thea", "tou", "peleidou"}, subseqLen: 1},
+		{from: []string{"menin", "aeide", "thea", "peleiadeo"}, to: []string{"ten", "orgen", "eipe", "thea", "tou", "peleidou"}, subseqLen: 2},
+		{from: []string{"oulomenen", "he", "muri"}, to: []string{"olethrian", "hetis", "muria"}, subseqLen: 3},
+	}
+	fs := []Feature{LexicalSimilarity, EditType}
+	ws := []float64{1.0, 0.1}
+	for _, v := range tt {
+		from := make([]Word, len(v.from))
+		for i, text := range v.from {
+			from[i] = Word{ID: GetWordID("HOM", fmt.Sprint(i+1)), Text: text, Source: "HOM"}
+		}
+		to := make([]Word, len(v.to))
+		for i, text := range v.to {
+			to[i] = Word{ID: GetWordID("PARA", fmt.Sprint(i+1)), Text: text, Source: "PARA"}
+		}
+
+		ctx := NewContext()
+		a, err := new(from, to).Align(NewMonotonicAligner(), fs, ws, v.subseqLen, ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := bestMonotonicScore(from, to, v.subseqLen, fs, ws, ctx)
+		if s := a.Score(fs, ws, ctx); math.Abs(s-expected) > 1e-9 {
+			t.Error("Expected ", expected, " got ", s, " for ", a)
+		}
+	}
+}
+
+// bestMonotonicScore enumerates every monotonic alignment of from and to
+func bestMonotonicScore(from, to []Word, subseqLen int, fs []Feature, ws []float64, ctx *Context) float64 {
+	if len(from) == 0 && len(to) == 0 {
+		return 0
+	}
+	best := math.Inf(-1)
+	try := func(e Edit, di, dj int) {
+		if s := e.Score(fs, ws, ctx) + bestMonotonicScore(from[di:], to[dj:], subseqLen, fs, ws, ctx); s > best {
+			best = s
+		}
+	}
+	if len(from) > 0 {
+		try(&Del{W: from[0]}, 1, 0)
+	}
+	if len(to) > 0 {
+		try(&Ins{W: to[0]}, 0, 1)
+	}
+	if len(from) > 0 && len(to) > 0 {
+		try(pairEdit(from[0], to[0]), 1, 1)
+	}
+	for n := 2; n <= subseqLen; n++ {
+		if len(from) > 0 && len(to) >= n {
+			try(&Sub{From: from[:1], To: to[:n]}, 1, n)
+		}
+		if len(to) > 0 && len(from) >= n {
+			try(&Sub{From: from[:n], To: to[:1]}, n, 1)
+		}
+	}
+	return best
+}
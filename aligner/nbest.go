@@ -0,0 +1,61 @@
+package aligner
+
+import "sort"
+
+// ScoredAlignment is a complete alignment with its model score
+type ScoredAlignment struct {
+	Alignment *Alignment
+	Score     float64
+	Margin    float64 // score of the best alignment minus this score
+}
+
+// AlignNBest returns at most n distinct complete alignments of a by decreasing
+// score. The candidates are the alignment chosen by the aligner, as returned by
+// Align, and the ones kept by a beam search over the edits of the greek aligner,
+// at least n wide or as wide as the beam aligner. As the aligners are not
+// exhaustive the beam can find a better alignment than the chosen one, so the
+// first alignment is the best of all the candidates and every margin is
+// relative to it, never negative. With n = 1 the chosen alignment is returned alone.
+func (a *Alignment) AlignNBest(ar Aligner, fs []Feature, ws []float64, subseqLen, n int, ctx *Context) ([]ScoredAlignment, error) {
+	if n < 1 {
+		return []ScoredAlignment{}, nil
+	}
+	chosen, err := a.Align(ar, fs, ws, subseqLen, ctx)
+	if err != nil {
+		return nil, err
+	}
+	if n == 1 {
+		return []ScoredAlignment{{Alignment: chosen, Score: chosen.Score(fs, ws, ctx)}}, nil
+	}
+
+	b := NewBeamAligner(n)
+	if ba, ok := ar.(*beamAligner); ok && ba.width > n {
+		b.width = ba.width
+	}
+	candidates := append([]Alignment{*chosen}, b.beamSearch(a, fs, ws, subseqLen, ctx)...)
+	scores := scoreCandidates(candidates, fs, ws, ctx)
+	keys := make([]string, len(candidates))
+	order := make([]int, len(candidates))
+	for i := range candidates {
+		keys[i], order[i] = candidates[i].key(), i
+	}
+	// ties are broken by the alignment key, as in Align
+	sort.Slice(order, func(i, j int) bool {
+		x, y := order[i], order[j]
+		return scores[x] > scores[y] || (scores[x] == scores[y] && keys[x] < keys[y])
+	})
+
+	res := []ScoredAlignment{}
+	seen := map[string]bool{}
+	for _, i := range order {
+		if len(res) == n {
+			break
+		}
+		if seen[keys[i]] {
+			continue
+		}
+		seen[keys[i]] = true
+		res = append(res, ScoredAlignment{Alignment: &candidates[i], Score: scores[i], Margin: scores[order[0]] - scores[i]})
+	}
+	return res, nil
+}
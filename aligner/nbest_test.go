@@ -0,0 +1,69 @@
+package aligner
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestAlignNBest(t *testing.T) {
+	from := WordsBag{}
+	to := WordsBag{}
+	for i, text := range []string{"menin", "aeide", "thea"} {
+		w := Word{ID: GetWordID("HOM", fmt.Sprint(i+1)), Text: text, Source: "HOM"}
+		from[w.ID] = w
+	}
+	for i, text := range []string{"ten", "orgen", "eipe", "thea,"} {
+		w := Word{ID: GetWordID("PARA", fmt.Sprint(i+1)), Text: text, Source: "PARA"}
+		to[w.ID] = w
+	}
+	fs := []Feature{LexicalSimilarity, EditType}
+	ws := []float64{1.0, 0.1}
+	ctx := NewContext()
+
+	tt := []struct {
+		ar Aligner
+		n  int
+	}{
+		{NewGreekAligner(), 0},
+		{NewGreekAligner(), 1},
+		{NewGreekAligner(), 4},
+		{NewBeamAligner(2), 4},
+		{NewHungarianAligner(), 3},
+	}
+	for _, x := range tt {
+		chosen, err := NewFromWordBags(from, to).Align(x.ar, fs, ws, 2, ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res, err := NewFromWordBags(from, to).AlignNBest(x.ar, fs, ws, 2, x.n, ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(res) != x.n {
+			t.Fatal("Expected ", x.n, " alignments got ", len(res))
+		}
+		if x.n == 0 {
+			continue
+		}
+		if s := chosen.Score(fs, ws, ctx); res[0].Score < s || res[0].Margin != 0 {
+			t.Error("Expected a first alignment scoring at least ", s, " got ", res[0].Score, " margin ", res[0].Margin)
+		}
+		seen := map[string]bool{}
+		for i, r := range res {
+			if k := r.Alignment.key(); seen[k] {
+				t.Error("Expected distinct alignments got ", r.Alignment, " twice")
+			} else {
+				seen[k] = true
+			}
+			if s := r.Alignment.Score(fs, ws, ctx); s != r.Score || res[0].Score-s != r.Margin {
+				t.Error("Expected score ", s, " got ", r.Score, " margin ", r.Margin)
+			}
+			if r.Margin < 0 {
+				t.Error("Expected a non negative margin got ", r.Margin)
+			}
+			if i > 0 && r.Score > res[i-1].Score {
+				t.Error("Expected decreasing scores got ", res[i-1].Score, " then ", r.Score)
+			}
+		}
+	}
+}
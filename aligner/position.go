@@ -0,0 +1,78 @@
+package aligner
+
+import (
+	"math"
+	"sort"
+)
+
+// versePosition is the place of a word among the words of its verse with the same source
+type versePosition struct {
+	index, len int
+}
+
+// relative returns the position normalized in [0, 1], 0.5 for a single word
+func (p versePosition) relative() float64 {
+	if p.len < 2 {
+		return 0.5
+	}
+	return float64(p.index) / float64(p.len-1)
+}
+
+// LoadVerses stores in the context the position of each word of the DB within
+// its verse, needed by RelativePosition
+func (c *Context) LoadVerses(db DB) {
+	positions := map[string]versePosition{}
+	for _, p := range ProblemsFromDB(db) {
+		for _, bag := range []WordsBag{p.From, p.To} {
+			ids := []string{}
+			for id := range bag {
+				ids = append(ids, id)
+			}
+			sort.Slice(ids, func(i, j int) bool { return wordIndex(ids[i]) < wordIndex(ids[j]) })
+			for i, id := range ids {
+				positions[id] = versePosition{index: i, len: len(ids)}
+			}
+		}
+	}
+	c.Set("VersePositions", positions)
+}
+
+// RelativePosition is 1 when the HOM and PARA words of the edit are at the same
+// relative position in their verses and decreases with the distance between their
+// mean positions and with the spread of the positions on each side of a Sub.
+// Ins and Del have no counterpart and get 0.
+func RelativePosition(e Edit, ctx *Context) float64 {
+	r, _ := ctx.Resource("VersePositions")
+	positions := r.(map[string]versePosition)
+	from, to := getWords(e)
+	meanFrom, spreadFrom, ok := positionStats(from, positions)
+	if !ok {
+		return 0
+	}
+	meanTo, spreadTo, ok := positionStats(to, positions)
+	if !ok {
+		return 0
+	}
+	return (1 - math.Abs(meanFrom-meanTo)) * (1 - math.Max(spreadFrom, spreadTo))
+}
+
+// positionStats returns the mean and the range of the relative positions of the
+// words, false if none of them has a known position
+func positionStats(ws []Word, positions map[string]versePosition) (float64, float64, bool) {
+	sum, min, max, n := 0.0, 1.0, 0.0, 0
+	for _, w := range ws {
+		p, ok := positions[w.ID]
+		if !ok {
+			continue
+		}
+		x := p.relative()
+		sum += x
+		min = math.Min(min, x)
+		max = math.Max(max, x)
+		n++
+	}
+	if n == 0 {
+		return 0, 0, false
+	}
+	return sum / float64(n), max - min, true
+}
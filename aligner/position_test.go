@@ -0,0 +1,46 @@
+package aligner
+
+import (
+	"math"
+	"testing"
+)
+
+func TestRelativePosition(t *testing.T) {
+	db := DB{}
+	words := map[string]Word{}
+	for _, w := range []Word{
+		{ID: "HOM.1", Text: "menin", Source: "HOM", Chant: "1", Verse: "1"},
+		{ID: "HOM.2", Text: "aeide", Source: "HOM", Chant: "1", Verse: "1"},
+		{ID: "HOM.3", Text: "thea", Source: "HOM", Chant: "1", Verse: "1"},
+		{ID: "PARA.1", Text: "ten", Source: "PARA", Chant: "1", Verse: "1"},
+		{ID: "PARA.2", Text: "orgen", Source: "PARA", Chant: "1", Verse: "1"},
+		{ID: "PARA.3", Text: "eipe", Source: "PARA", Chant: "1", Verse: "1"},
+		{ID: "PARA.4", Text: "moi", Source: "PARA", Chant: "1", Verse: "1"},
+		{ID: "PARA.5", Text: "thea", Source: "PARA", Chant: "1", Verse: "1"},
+		{ID: "HOM.4", Text: "oulomenen", Source: "HOM", Chant: "1", Verse: "2"},
+		{ID: "PARA.6", Text: "olethrian", Source: "PARA", Chant: "1", Verse: "2"},
+	} {
+		db[w.ID] = w
+		words[w.ID] = w
+	}
+	ctx := NewContext()
+	ctx.LoadVerses(db)
+
+	tt := []struct {
+		e        Edit
+		expected float64
+	}{
+		{&Eq{From: words["HOM.3"], To: words["PARA.5"]}, 1},
+		{&Sub{From: []Word{words["HOM.1"]}, To: []Word{words["PARA.5"]}}, 0},
+		{&Sub{From: []Word{words["HOM.2"]}, To: []Word{words["PARA.2"]}}, 0.75},
+		{&Sub{From: []Word{words["HOM.1"]}, To: []Word{words["PARA.1"], words["PARA.2"]}}, (1 - 0.125) * 0.75},
+		{&Sub{From: []Word{words["HOM.4"]}, To: []Word{words["PARA.6"]}}, 1},
+		{&Del{W: words["HOM.1"]}, 0},
+		{&Ins{W: words["PARA.1"]}, 0},
+	}
+	for _, x := range tt {
+		if v := RelativePosition(x.e, ctx); math.Abs(v-x.expected) > 1e-9 {
+			t.Error("Expected ", x.expected, " got ", v, " for ", x.e)
+		}
+	}
+}
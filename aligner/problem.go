@@ -0,0 +1,55 @@
+package aligner
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ProblemsFromDB groups the words of the database in problems, one for each chant.verse
+func ProblemsFromDB(db DB) map[string]Problem {
+	problems := map[string]Problem{}
+	for _, w := range db {
+		id := w.getProblemID()
+		if _, ok := problems[id]; !ok {
+			problems[id] = Problem{From: WordsBag{}, To: WordsBag{}}
+		}
+		switch w.Source {
+		case "HOM":
+			problems[id].From[w.ID] = w
+		case "PARA":
+			problems[id].To[w.ID] = w
+		}
+	}
+	return problems
+}
+
+// SortProblemIDs sorts chant.verse problem IDs by chant and then by verse
+func SortProblemIDs(ids []string) {
+	sort.SliceStable(ids, func(i, j int) bool { return ProblemIDLess(ids[i], ids[j]) })
+}
+
+// ProblemIDLess reports whether the problem a comes before the problem b
+func ProblemIDLess(a, b string) bool {
+	x := strings.SplitN(a, ".", 2)
+	y := strings.SplitN(b, ".", 2)
+	for i := 0; i < len(x) && i < len(y); i++ {
+		if x[i] == y[i] {
+			continue
+		}
+		n, errN := strconv.Atoi(x[i])
+		m, errM := strconv.Atoi(y[i])
+		if errN != nil || errM != nil || n == m {
+			return x[i] < y[i]
+		}
+		return n < m
+	}
+	return len(x) < len(y)
+}
+
+// wordIndex returns the position of the word in its source given its ID
+func wordIndex(id string) int {
+	parts := strings.Split(id, ".")
+	n, _ := strconv.Atoi(parts[len(parts)-1])
+	return n
+}
@@ -0,0 +1,123 @@
+package aligner
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// FeatureInfo describes a feature registered under a stable name
+type FeatureInfo struct {
+	Name        string
+	Feature     Feature
+	Description string
+	Resources   []string // keys of the additional data needed to compute the feature
+	Min, Max    float64  // range of the feature values
+}
+
+var registry = map[string]FeatureInfo{}
+
+func init() {
+	for _, info := range []FeatureInfo{
+		{Name: "EditType", Feature: EditType, Max: 10, Description: "constant score depending on the edit type"},
+		{Name: "LexicalSimilarity", Feature: LexicalSimilarity, Max: 1, Description: "normalized edit distance between the texts"},
+		{Name: "LemmaDistance", Feature: LemmaDistance, Max: 1, Description: "normalized edit distance between the lemmas"},
+		{Name: "TextualDistance", Feature: TextualDistance, Max: 1, Description: "max between LexicalSimilarity and LemmaDistance"},
+		{Name: "TagDistance", Feature: TagDistance, Max: 1, Description: "normalized edit distance between the morphological tags"},
+		{Name: "TagPOS", Feature: TagPOS, Max: 1, Description: "1 if the words have the same part of speech in their morphological tags"},
+		{Name: "TagPerson", Feature: TagPerson, Max: 1, Description: "1 if the words have the same person in their morphological tags"},
+		{Name: "TagNumber", Feature: TagNumber, Max: 1, Description: "1 if the words have the same number in their morphological tags"},
+		{Name: "TagTense", Feature: TagTense, Max: 1, Description: "1 if the words have the same tense in their morphological tags"},
+		{Name: "TagMood", Feature: TagMood, Max: 1, Description: "1 if the words have the same mood in their morphological tags"},
+		{Name: "TagVoice", Feature: TagVoice, Max: 1, Description: "1 if the words have the same voice in their morphological tags"},
+		{Name: "TagGender", Feature: TagGender, Max: 1, Description: "1 if the words have the same gender in their morphological tags"},
+		{Name: "TagCase", Feature: TagCase, Max: 1, Description: "1 if the words have the same case in their morphological tags"},
+		{Name: "TagAgreement", Feature: TagAgreement, Max: 1, Description: "fraction of case, gender and number agreeing among the words on each side of a Sub"},
+		{Name: "VocDistance", Feature: VocDistance, Max: 1, Resources: []string{"VocDistance"}, Description: "1 if the lemmas have the same meaning in the vocabulary"},
+		{Name: "EqEquivTermDistance", Feature: EqEquivTermDistance, Max: 1, Resources: []string{"EquivTermDistance"}, Description: "1 if the paraphrase lemma is an equivalent term of the Homer lemma"},
+		{Name: "ScholieDistance", Feature: ScholieDistance, Max: 1, Resources: []string{"ScholieDistance"}, Description: "similarity of the paraphrase text with the scholie glosses of the Homer text"},
+		{Name: "ScholieDistanceExact", Feature: ScholieDistanceExact, Max: 1, Resources: []string{"ScholieDistanceExact"}, Description: "similarity of the paraphrase text with the scholie glosses of the exact Homer text"},
+		{Name: "RelativePosition", Feature: RelativePosition, Max: 1, Resources: []string{"VersePositions"}, Description: "closeness of the relative positions of the words in their verses, lower for spread out Sub"},
+		{Name: "IBM1Lemma", Feature: IBM1Lemma, Max: 1, Resources: []string{"IBMModel1Lemma"}, Description: "IBM Model 1 probability of translating the Homer lemmas by the paraphrase ones"},
+		{Name: "IBM1Text", Feature: IBM1Text, Max: 1, Resources: []string{"IBMModel1Text"}, Description: "IBM Model 1 probability of translating the Homer texts by the paraphrase ones"},
+		{Name: "LemmaDice", Feature: LemmaDice, Max: 1, Resources: []string{"LemmaAssociation"}, Description: "Dice coefficient of the lemmas linked in the training gold alignments"},
+		{Name: "LemmaPMI", Feature: LemmaPMI, Min: -1, Max: 1, Resources: []string{"LemmaAssociation"}, Description: "normalized PMI of the lemmas linked in the training gold alignments"},
+		{Name: "MaxDistance", Feature: MaxDistance, Max: 1, Resources: []string{"VocDistance", "EquivTermDistance", "ScholieDistance"}, Description: "max of the lexical, lemma, tag, vocabulary, scholie and equivalent terms features"},
+	} {
+		if err := RegisterFeature(info); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// RegisterFeature adds a feature to the registry
+func RegisterFeature(info FeatureInfo) error {
+	if info.Name == "" || info.Feature == nil {
+		return fmt.Errorf("feature must have a name and a function")
+	}
+	if _, ok := registry[info.Name]; ok {
+		return fmt.Errorf("feature %q already registered", info.Name)
+	}
+	registry[info.Name] = info
+	return nil
+}
+
+// LookupFeature returns the information of the feature registered with the given name
+func LookupFeature(name string) (FeatureInfo, error) {
+	info, ok := registry[name]
+	if !ok {
+		return FeatureInfo{}, fmt.Errorf("unknown feature %q, available features: %s", name, strings.Join(FeatureNames(), ", "))
+	}
+	return info, nil
+}
+
+// FeatureByName returns the feature registered with the given name
+func FeatureByName(name string) (Feature, error) {
+	info, err := LookupFeature(name)
+	if err != nil {
+		return nil, err
+	}
+	return info.Feature, nil
+}
+
+// FeatureNames returns the sorted names of the registered features
+func FeatureNames() []string {
+	names := []string{}
+	for k := range registry {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NeedsResource reports whether one of the named features requires the resource
+func NeedsResource(names []string, resource string) bool {
+	for _, name := range names {
+		info, err := LookupFeature(name)
+		if err != nil {
+			continue
+		}
+		for _, r := range info.Resources {
+			if r == resource {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// CheckResources verifies that the context contains the resources needed by the named features
+func CheckResources(names []string, ctx *Context) error {
+	for _, name := range names {
+		info, err := LookupFeature(name)
+		if err != nil {
+			return err
+		}
+		for _, r := range info.Resources {
+			if _, ok := ctx.Resource(r); !ok {
+				return fmt.Errorf("feature %s requires the %s resource which was not loaded", name, r)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,22 @@
+package aligner
+
+import "testing"
+
+func TestCheckResources(t *testing.T) {
+	tt := []struct {
+		names []string
+		data  map[string]interface{}
+		ok    bool
+	}{
+		{names: []string{"TagDistance"}, data: map[string]interface{}{}, ok: true},
+		{names: []string{"TagDistance", "VocDistance"}, data: map[string]interface{}{}, ok: false},
+		{names: []string{"VocDistance"}, data: map[string]interface{}{"VocDistance": map[string][]string{}}, ok: true},
+		{names: []string{"Unknown"}, data: map[string]interface{}{}, ok: false},
+	}
+	for _, v := range tt {
+		err := CheckResources(v.names, NewContextFromData(v.data))
+		if (err == nil) != v.ok {
+			t.Error("Expected ok ", v.ok, " for ", v.names, " got ", err)
+		}
+	}
+}
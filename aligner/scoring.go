@@ -0,0 +1,80 @@
+package aligner
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// scoreCandidates scores the alignments using at most ctx.Workers() goroutines
+func scoreCandidates(F []Alignment, fs []Feature, ws []float64, ctx *Context) []float64 {
+	scores := make([]float64, len(F))
+	workers := ctx.Workers()
+	if workers > len(F) {
+		workers = len(F)
+	}
+	if workers <= 1 {
+		for i := range F {
+			scores[i] = F[i].Score(fs, ws, ctx)
+		}
+		return scores
+	}
+
+	var next int32 = -1
+	var wg sync.WaitGroup
+	for k := 0; k < workers; k++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := int(atomic.AddInt32(&next, 1)); i < len(F); i = int(atomic.AddInt32(&next, 1)) {
+				scores[i] = F[i].Score(fs, ws, ctx)
+			}
+		}()
+	}
+	wg.Wait()
+	return scores
+}
+
+// bestCandidate returns the index of the alignment with the highest score.
+// Ties are broken by the smallest alignment key so that the choice does not
+// depend on the order of the candidates.
+func bestCandidate(F []Alignment, scores []float64) int {
+	keys := make([]string, len(F))
+	key := func(i int) string {
+		if keys[i] == "" {
+			keys[i] = F[i].key()
+		}
+		return keys[i]
+	}
+	best := 0
+	for i := 1; i < len(F); i++ {
+		if scores[i] > scores[best] || (scores[i] == scores[best] && key(i) < key(best)) {
+			best = i
+		}
+	}
+	return best
+}
+
+// key identifies the alignment by its edits and their words
+func (a *Alignment) key() string {
+	keys := make([]string, 0, len(a.editMap))
+	for e := range a.editMap {
+		keys = append(keys, editKey(e))
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ";")
+}
+
+func editKey(e Edit) string {
+	from, to := getWords(e)
+	ids := func(ws []Word) string {
+		s := make([]string, len(ws))
+		for i, w := range ws {
+			s[i] = w.ID
+		}
+		return strings.Join(s, ",")
+	}
+	return fmt.Sprintf("%T(%s|%s)", e, ids(from), ids(to))
+}
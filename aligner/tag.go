@@ -0,0 +1,178 @@
+package aligner
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Morph holds the attributes of a morphological tag of the words DB, empty when
+// the tag does not specify them. Tags have the form POS[+Subtype][:attributes],
+// e.g. N+Com:Gms for a common noun genitive masculine singular, PRO+Per1s:Dms for a
+// personal pronoun of the first person singular dative masculine, V:EÎJ3s for a
+// verb active indicative aorist third person singular and V:EKPNms for its
+// present participle nominative masculine singular.
+type Morph struct {
+	POS     string
+	Subtype string
+	Person  string
+	Number  string
+	Tense   string
+	Mood    string
+	Voice   string
+	Gender  string
+	Case    string
+}
+
+// Values of the attributes recognized by ParseTag. The mood (Î indicative, S
+// subjunctive, O optative, Y imperative, W infinitive, K participle, ...) and the
+// tense (P present, I imperfect, F future, J aorist, R perfect, ...) of the verbs
+// follow the voice and are kept as they are.
+const (
+	tagVoices = "EMB" // active, middle, passive
+	tagCases  = "NGDAV"
+	tagGender = "mfn"
+	tagNumber = "sdp"
+)
+
+// ParseTag splits a morphological tag into its attributes. Alternative analyses
+// after @ are ignored and unknown characters are skipped.
+func ParseTag(tag string) Morph {
+	tag = strings.TrimSpace(strings.SplitN(tag, "@", 2)[0])
+	parts := strings.Split(tag, ":")
+	head := strings.Split(parts[0], "+")
+
+	m := Morph{POS: head[0]}
+	if len(head) > 1 {
+		// PRO+Per1s, PRO+Pos2p, ... carry the person and number of the pronoun
+		sub := []rune(head[1])
+		i := 0
+		for i < len(sub) && !unicode.IsDigit(sub[i]) {
+			i++
+		}
+		m.Subtype = string(sub[:i])
+		m.Person, m.Number = parsePersonNumber(sub[i:])
+	}
+	if len(parts) < 2 {
+		return m
+	}
+
+	attrs := []rune(parts[1])
+	if m.POS == "V" && len(attrs) >= 3 && strings.ContainsRune(tagVoices, attrs[0]) {
+		m.Voice, m.Mood, m.Tense = string(attrs[0]), string(attrs[1]), string(attrs[2])
+		attrs = attrs[3:]
+		if len(attrs) > 0 && unicode.IsDigit(attrs[0]) {
+			m.Person, m.Number = parsePersonNumber(attrs)
+			return m
+		}
+	}
+	// case, gender and number, each of them may be missing
+	for _, slot := range []struct {
+		values string
+		field  *string
+	}{{tagCases, &m.Case}, {tagGender, &m.Gender}, {tagNumber, &m.Number}} {
+		if len(attrs) > 0 && strings.ContainsRune(slot.values, attrs[0]) {
+			*slot.field = string(attrs[0])
+			attrs = attrs[1:]
+		}
+	}
+	return m
+}
+
+// parsePersonNumber reads a person digit followed by a number, as in 3s
+func parsePersonNumber(rs []rune) (string, string) {
+	person, number := "", ""
+	if len(rs) > 0 && unicode.IsDigit(rs[0]) {
+		person = string(rs[0])
+		rs = rs[1:]
+	}
+	if len(rs) > 0 && strings.ContainsRune(tagNumber, unicode.ToLower(rs[0])) {
+		number = string(unicode.ToLower(rs[0]))
+	}
+	return person, number
+}
+
+// Features comparing a single attribute of the tags of the HOM and PARA words
+var (
+	TagPOS    = tagAttributeFeature("TagPOS", func(m Morph) string { return m.POS })
+	TagPerson = tagAttributeFeature("TagPerson", func(m Morph) string { return m.Person })
+	TagNumber = tagAttributeFeature("TagNumber", func(m Morph) string { return m.Number })
+	TagTense  = tagAttributeFeature("TagTense", func(m Morph) string { return m.Tense })
+	TagMood   = tagAttributeFeature("TagMood", func(m Morph) string { return m.Mood })
+	TagVoice  = tagAttributeFeature("TagVoice", func(m Morph) string { return m.Voice })
+	TagGender = tagAttributeFeature("TagGender", func(m Morph) string { return m.Gender })
+	TagCase   = tagAttributeFeature("TagCase", func(m Morph) string { return m.Case })
+)
+
+// tagAttributeFeature returns a feature that is 1 when a HOM word and a PARA word
+// of the edit have the same value of the attribute, 0 otherwise or when the
+// attribute is missing on one side
+func tagAttributeFeature(name string, attr func(Morph) string) Feature {
+	return func(e Edit, ctx *Context) float64 {
+		if v, ok := ctx.cachedScore(name, e); ok {
+			return v
+		}
+		from, to := getWords(e)
+		values := map[string]bool{}
+		for _, w := range from {
+			if v := attr(ParseTag(w.Tag)); v != "" {
+				values[v] = true
+			}
+		}
+		res := 0.0
+		for _, w := range to {
+			if values[attr(ParseTag(w.Tag))] {
+				res = 1
+			}
+		}
+		ctx.cacheScore(name, e, res)
+		return res
+	}
+}
+
+// TagAgreement checks that the words on each side of a Sub agree in case, gender
+// and number, as an article with its noun. It is the fraction of these attributes
+// with the same value among the words of a side specifying them, counting only
+// the attributes specified by at least two words. It is 0 when there is nothing to check.
+func TagAgreement(e Edit, ctx *Context) float64 {
+	if v, ok := ctx.cachedScore("TagAgreement", e); ok {
+		return v
+	}
+	from, to := getWords(e)
+	checked, agree := 0, 0
+	for _, ws := range [][]Word{from, to} {
+		if len(ws) < 2 {
+			continue
+		}
+		morphs := make([]Morph, len(ws))
+		for i, w := range ws {
+			morphs[i] = ParseTag(w.Tag)
+		}
+		for _, attr := range []func(Morph) string{
+			func(m Morph) string { return m.Case },
+			func(m Morph) string { return m.Gender },
+			func(m Morph) string { return m.Number },
+		} {
+			values := map[string]int{}
+			n := 0
+			for _, m := range morphs {
+				if v := attr(m); v != "" {
+					values[v]++
+					n++
+				}
+			}
+			if n < 2 {
+				continue
+			}
+			checked++
+			if len(values) == 1 {
+				agree++
+			}
+		}
+	}
+	res := 0.0
+	if checked > 0 {
+		res = float64(agree) / float64(checked)
+	}
+	ctx.cacheScore("TagAgreement", e, res)
+	return res
+}
@@ -0,0 +1,62 @@
+package aligner
+
+import "testing"
+
+func TestParseTag(t *testing.T) {
+	tt := []struct {
+		tag      string
+		expected Morph
+	}{
+		{"", Morph{}},
+		{"I+Part", Morph{POS: "I", Subtype: "Part"}},
+		{"N+Com:Gms", Morph{POS: "N", Subtype: "Com", Case: "G", Gender: "m", Number: "s"}},
+		{"DET:mp", Morph{POS: "DET", Gender: "m", Number: "p"}},
+		{"PRO+Ind:Nms", Morph{POS: "PRO", Subtype: "Ind", Case: "N", Gender: "m", Number: "s"}},
+		{"PRO+Per1s:Dms", Morph{POS: "PRO", Subtype: "Per", Person: "1", Case: "D", Gender: "m", Number: "s"}},
+		{"PRO+Rel:Gns@I+AdvPr", Morph{POS: "PRO", Subtype: "Rel", Case: "G", Gender: "n", Number: "s"}},
+		{"V:EÎJ3s", Morph{POS: "V", Voice: "E", Mood: "Î", Tense: "J", Person: "3", Number: "s"}},
+		{"V:MÎP1S", Morph{POS: "V", Voice: "M", Mood: "Î", Tense: "P", Person: "1", Number: "s"}},
+		{"V:EKPNms", Morph{POS: "V", Voice: "E", Mood: "K", Tense: "P", Case: "N", Gender: "m", Number: "s"}},
+		{"V:EWJ", Morph{POS: "V", Voice: "E", Mood: "W", Tense: "J"}},
+		{"V:Nms", Morph{POS: "V", Case: "N", Gender: "m", Number: "s"}},
+		{"A:Gmp:Gmp", Morph{POS: "A", Case: "G", Gender: "m", Number: "p"}},
+	}
+	for _, x := range tt {
+		if m := ParseTag(x.tag); m != x.expected {
+			t.Error("Expected ", x.expected, " got ", m, " for ", x.tag)
+		}
+	}
+}
+
+func TestTagFeatures(t *testing.T) {
+	art := Word{ID: "HOM.1", Text: "tou", Tag: "DET:Gms", Source: "HOM"}
+	noun := Word{ID: "HOM.2", Text: "dios", Tag: "N+Ant:Gms", Source: "HOM"}
+	verb := Word{ID: "HOM.3", Text: "eipe", Tag: "V:EÎJ3s", Source: "HOM"}
+	part := Word{ID: "HOM.4", Text: "de", Tag: "I+Part", Source: "HOM"}
+	paraNoun := Word{ID: "PARA.1", Text: "dios", Tag: "N+Ant:Gms", Source: "PARA"}
+	paraVerb := Word{ID: "PARA.2", Text: "eipen", Tag: "V:EÎJ3p", Source: "PARA"}
+	paraArt := Word{ID: "PARA.3", Text: "ten", Tag: "DET:Afs", Source: "PARA"}
+
+	tt := []struct {
+		f        Feature
+		e        Edit
+		expected float64
+	}{
+		{TagCase, &Eq{From: noun, To: paraNoun}, 1},
+		{TagCase, &Sub{From: []Word{verb}, To: []Word{paraVerb}}, 0},
+		{TagTense, &Sub{From: []Word{verb}, To: []Word{paraVerb}}, 1},
+		{TagNumber, &Sub{From: []Word{verb}, To: []Word{paraVerb}}, 0},
+		{TagPOS, &Sub{From: []Word{art, noun}, To: []Word{paraNoun}}, 1},
+		{TagPOS, &Del{W: noun}, 0},
+		{TagAgreement, &Sub{From: []Word{art, noun}, To: []Word{paraNoun}}, 1},
+		{TagAgreement, &Sub{From: []Word{noun}, To: []Word{paraArt, paraNoun}}, 1.0 / 3}, // only the number agrees
+		{TagAgreement, &Sub{From: []Word{art, part}, To: []Word{paraNoun}}, 0},
+		{TagAgreement, &Eq{From: noun, To: paraNoun}, 0},
+	}
+	ctx := NewContext()
+	for _, x := range tt {
+		if v := x.f(x.e, ctx); v != x.expected {
+			t.Error("Expected ", x.expected, " got ", v, " for ", x.e)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	aligner "github.com/szenzaro/iliad-aligner/aligner"
+)
+
+func diffCmd(args []string) {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	af := addModelFlags(fs, "data/G44_I_III_HomPara.xlsx")
+	verse := fs.String("verse", "", "ID of the verse to compare, e.g. 1.5")
+	goldPath := fs.String("gold", "data/G44_ALI.tmx", "alignment file (tmx or json) used as reference")
+	predictedPath := fs.String("predicted", "", "alignment file (tmx or json) to compare, by default the verse is aligned with the model")
+	fs.Parse(args)
+
+	if *verse == "" {
+		log.Fatalln("missing -verse")
+	}
+
+	fmt.Println("Loading words database")
+	wordsDB, err := aligner.LoadDB(strings.Split(*af.paths.words, ","))
+	if err != nil {
+		log.Fatalln(err)
+	}
+	gold, err := readAlignments(*goldPath, wordsDB)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	var predicted map[string]*aligner.Alignment
+	if *predictedPath != "" {
+		predicted, err = readAlignments(*predictedPath, wordsDB)
+		if err != nil {
+			log.Fatalln(err)
+		}
+	} else {
+		model, ctx := af.loadModelFor(wordsDB)
+		predicted = af.alignWith(model, ctx, wordsDB, func(id string) bool { return id == *verse })
+	}
+
+	g, ok := gold[*verse]
+	if !ok {
+		log.Fatalln("verse ", *verse, " not found in ", *goldPath)
+	}
+	p, ok := predicted[*verse]
+	if !ok {
+		log.Fatalln("verse ", *verse, " not found in the predicted alignments")
+	}
+	printDiff(os.Stdout, *verse, g, p)
+}
+
+// readAlignments loads the alignments by problem ID of a TMX file or of a JSON
+// file written by the align command
+func readAlignments(path string, words aligner.DB) (map[string]*aligner.Alignment, error) {
+	res := map[string]*aligner.Alignment{}
+	if strings.ToLower(filepath.Ext(path)) == ".tmx" {
+		for _, g := range loadGoldStandard(path, words) {
+			res[g.ID] = g.a
+		}
+		return res, nil
+	}
+
+	d, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	data := map[string][]aligner.JSONEdit{}
+	if err := json.Unmarshal(d, &data); err != nil {
+		return nil, err
+	}
+	for id, edits := range data {
+		a := aligner.NewFromEdits()
+		for _, je := range edits {
+			e, err := je.ToEdit(words)
+			if err != nil {
+				return nil, fmt.Errorf("verse %s: %v", id, err)
+			}
+			a.Add(e)
+		}
+		res[id] = a
+	}
+	return res, nil
+}
+
+// printDiff writes the words of the verse in order with their gold and predicted
+// edits side by side. Agreements are marked with = and disagreements with ! followed
+// by the error category.
+func printDiff(out io.Writer, id string, gold, predicted *aligner.Alignment) {
+	diffs := aligner.DiffWords(gold, predicted)
+	agree := 0
+	for _, d := range diffs {
+		if d.Category() == aligner.CategoryCorrect {
+			agree++
+		}
+	}
+	fmt.Fprintf(out, "Verse %s: %d/%d words agree\n\n", id, agree, len(diffs))
+
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "\tword\tgold\tpredicted\t")
+	source := ""
+	for _, d := range diffs {
+		if s := strings.SplitN(d.Word.ID, ".", 2)[0]; s != source {
+			source = s
+			fmt.Fprintf(w, "%s\t\t\t\t\n", source)
+		}
+		mark, category := "=", ""
+		if c := d.Category(); c != aligner.CategoryCorrect {
+			mark, category = "!", c
+		}
+		fmt.Fprintf(w, "%s\t%s %s\t%s\t%s\t%s\n", mark, d.Word.ID, d.Word.Text, describeEdit(d.Gold, d.Word), describeEdit(d.Predicted, d.Word), category)
+	}
+	w.Flush()
+}
+
+// describeEdit shows the type of the edit of the word w with the other words of
+// the edit: the ones on the same side in parentheses and the linked ones after the arrow
+func describeEdit(e aligner.Edit, w aligner.Word) string {
+	if e == nil {
+		return "-"
+	}
+	same, linked := aligner.EditWords(e)
+	if w.Source == "PARA" {
+		same, linked = linked, same
+	}
+	texts := func(ws []aligner.Word) string {
+		res := []string{}
+		for _, x := range ws {
+			if x.ID != w.ID {
+				res = append(res, x.Text)
+			}
+		}
+		return strings.Join(res, " ")
+	}
+
+	desc := strings.ToLower(aligner.EditTypeName(e))
+	if t := texts(same); t != "" {
+		desc += " (+ " + t + ")"
+	}
+	if t := texts(linked); t != "" {
+		desc += " → " + t
+	}
+	return desc
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"runtime"
+	"sync"
+	"sync/atomic"
+
+	aligner "github.com/szenzaro/iliad-aligner/aligner"
+)
+
+// verseResult holds the evaluation of the alignment of a single verse
+type verseResult struct {
+	ID            string
+	Alignment     *aligner.Alignment
+	Gold          *aligner.Alignment
+	HOMWords      int
+	PARAWords     int
+	ScoreAccuracy float64
+	EditAccuracy  float64
+	Links         aligner.LinkCounts
+}
+
+// evaluate aligns the problems of the test set with a pool of workers and
+// compares them with the gold standard. The results follow the order of testSet.
+func evaluate(testSet []goldStandard, workers int, ctx *aligner.Context, ar aligner.Aligner, ff []aligner.Feature, w []float64, subseqLen int) []verseResult {
+	results := make([]verseResult, len(testSet))
+	var done int32
+	forEachParallel(len(testSet), workers, ctx, func(i int, c *aligner.Context) {
+		p := testSet[i]
+		c.ResetCache()
+		res, err := aligner.NewFromWordBags(p.p.From, p.p.To).Align(ar, ff, w, subseqLen, c)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		results[i] = verseResult{
+			ID:            p.ID,
+			Alignment:     res,
+			Gold:          p.a,
+			HOMWords:      len(p.p.From),
+			PARAWords:     len(p.p.To),
+			ScoreAccuracy: aligner.ScoreAccuracy(p.a, res, ff, w, c),
+			EditAccuracy:  res.EditsAccuracy(p.a),
+			Links:         aligner.CompareLinks(p.a, res),
+		}
+		m := results[i].metrics()
+		fmt.Println(p.ID, " ", atomic.AddInt32(&done, 1), "/", len(testSet), " P ", m.Precision, " R ", m.Recall, " F1 ", m.F1, " AER ", m.AER)
+	})
+	return results
+}
+
+// metrics holds the measures of the alignment of a test set
+type metrics struct {
+	EditAccuracy  float64 `json:"editAccuracy"`
+	ScoreAccuracy float64 `json:"scoreAccuracy"`
+	Precision     float64 `json:"precision"`
+	Recall        float64 `json:"recall"`
+	F1            float64 `json:"f1"`
+	AER           float64 `json:"aer"`
+}
+
+// metricNames are the names of the metrics in the order of fields
+var metricNames = []string{"Edit Accuracy", "Score Accuracy", "Precision", "Recall", "F1", "AER"}
+
+// fields returns pointers to every metric, so that they can be aggregated together
+func (m *metrics) fields() []*float64 {
+	return []*float64{&m.EditAccuracy, &m.ScoreAccuracy, &m.Precision, &m.Recall, &m.F1, &m.AER}
+}
+
+// linkMetrics returns the metrics computed from the word links
+func linkMetrics(c aligner.LinkCounts) metrics {
+	return metrics{Precision: c.Precision(), Recall: c.Recall(), F1: c.F1(), AER: c.AER()}
+}
+
+// metrics returns the metrics of a single verse
+func (r verseResult) metrics() metrics {
+	m := linkMetrics(r.Links)
+	m.EditAccuracy, m.ScoreAccuracy = r.EditAccuracy, r.ScoreAccuracy
+	return m
+}
+
+// aggregateMetrics returns the metrics of a set of verses, a fold or a chant:
+// the mean of their score and edit accuracies, and the link precision, recall,
+// F1 and AER micro-averaged on the links of all the verses together, as usual in
+// the alignment literature. The metrics are 0 without results.
+func aggregateMetrics(results []verseResult) metrics {
+	if len(results) == 0 {
+		return metrics{}
+	}
+	links := aligner.LinkCounts{}
+	for _, r := range results {
+		links = links.Add(r.Links)
+	}
+	m := linkMetrics(links)
+	for _, r := range results {
+		m.ScoreAccuracy += r.ScoreAccuracy
+		m.EditAccuracy += r.EditAccuracy
+	}
+	m.ScoreAccuracy /= float64(len(results))
+	m.EditAccuracy /= float64(len(results))
+	return m
+}
+
+// meanStd returns the mean and the sample standard deviation of every metric,
+// the deviation is 0 for a single value
+func meanStd(ms []metrics) (metrics, metrics) {
+	mean, std := metrics{}, metrics{}
+	meanFields, stdFields := mean.fields(), std.fields()
+	for i := range meanFields {
+		for _, m := range ms {
+			*meanFields[i] += *m.fields()[i]
+		}
+		*meanFields[i] /= float64(len(ms))
+		if len(ms) < 2 {
+			continue
+		}
+		for _, m := range ms {
+			d := *m.fields()[i] - *meanFields[i]
+			*stdFields[i] += d * d
+		}
+		*stdFields[i] = math.Sqrt(*stdFields[i] / float64(len(ms)-1))
+	}
+	return mean, std
+}
+
+// forEachParallel calls f for each index in [0, n) from a pool of workers.
+// Each worker gets its own fork of ctx so that the caches are not shared. When
+// there are several workers the forks score the candidates sequentially, not to
+// start workers² goroutines. A non positive number of workers uses all the available CPUs.
+func forEachParallel(n, workers int, ctx *aligner.Context, f func(int, *aligner.Context)) {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for k := 0; k < workers; k++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c := ctx.Fork()
+			if workers > 1 {
+				c.SetWorkers(1)
+			}
+			for i := range jobs {
+				f(i, c)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+}
@@ -0,0 +1,467 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	aligner "github.com/szenzaro/iliad-aligner/aligner"
+)
+
+// experimentConfig describes an experiment: the data to use, the feature sets to
+// evaluate and how to train and test each of them
+type experimentConfig struct {
+	Data     experimentData      `json:"data"`
+	Features experimentFeatures  `json:"features"`
+	Split    experimentSplit     `json:"split"`
+	Learner  aligner.LearnParams `json:"learner"`
+	Aligner  string              `json:"aligner"`
+	Workers  int                 `json:"workers"` // parallel alignments during the test, 0 uses all the CPUs
+	Output   experimentOutput    `json:"output"`
+}
+
+type experimentData struct {
+	Words        string `json:"words"`
+	GoldStandard string `json:"goldStandard"`
+	Voc          string `json:"voc"`
+	Equiv        string `json:"equiv"`
+	Scholie      string `json:"scholie"`
+	IBM1         string `json:"ibm1,omitempty"`
+}
+
+// experimentFeatures lists the feature sets to evaluate. When PowerSet is true
+// every non empty subset of Names is evaluated, otherwise Sets is used.
+type experimentFeatures struct {
+	Names    []string   `json:"names,omitempty"`
+	PowerSet bool       `json:"powerSet,omitempty"`
+	Sets     [][]string `json:"sets,omitempty"`
+}
+
+// experimentSplit selects the training and test sets: "head" takes the first
+// Train fraction of the gold standard while "random" shuffles it first,
+// "kfold" tests each of Folds random folds training on the others and "chant"
+// tests each chant training on the other ones
+type experimentSplit struct {
+	Strategy string  `json:"strategy"`
+	Train    float64 `json:"train,omitempty"`
+	Folds    int     `json:"folds,omitempty"`
+}
+
+type experimentOutput struct {
+	Log    string `json:"log"`
+	Models string `json:"models,omitempty"`
+}
+
+func defaultExperimentConfig() experimentConfig {
+	return experimentConfig{
+		Data: experimentData{
+			Words:        "data/G44_I_III_HomPara.xlsx",
+			GoldStandard: "data/G44_ALI.tmx",
+			Voc:          "data/Vocabulaire_Genavensis.xlsx",
+			Equiv:        "data/Lexique Homer termes Equivalents 1-3.xlsx",
+			Scholie:      "data/scholied.json",
+		},
+		Features: experimentFeatures{
+			Names:    parseFeatureNames(defaultFeatures),
+			PowerSet: true,
+		},
+		Split:   experimentSplit{Strategy: "head", Train: 0.3},
+		Learner: aligner.LearnParams{N: 50, N0: 10, R0: 1.0, R: 0.8, SubseqLen: 1, Seed: 1},
+		Aligner: "greek",
+		Output:  experimentOutput{Log: "out/test.log"},
+	}
+}
+
+func loadExperimentConfig(path string) (experimentConfig, error) {
+	cfg := defaultExperimentConfig()
+	d, err := ioutil.ReadFile(path)
+	if err != nil {
+		return cfg, err
+	}
+	cfg.Features = experimentFeatures{}
+	if err := json.Unmarshal(d, &cfg); err != nil {
+		return cfg, fmt.Errorf("%s: %v", path, err)
+	}
+	return cfg, nil
+}
+
+func (d experimentData) paths() dataPaths {
+	return dataPaths{words: &d.Words, goldStandard: &d.GoldStandard, voc: &d.Voc, equiv: &d.Equiv, scholie: &d.Scholie, ibm1: &d.IBM1}
+}
+
+// featureSets returns the names of the features of each test
+func (f experimentFeatures) featureSets() [][]string {
+	if !f.PowerSet {
+		return f.Sets
+	}
+	sets := [][]string{}
+	for _, s := range powerSet(f.Names) {
+		if len(s) > 0 {
+			sets = append(sets, s)
+		}
+	}
+	return sets
+}
+
+// resourceData maps the additional data resources to the data file they are loaded from
+var resourceData = map[string]string{
+	"VocDistance":          "voc",
+	"EquivTermDistance":    "equiv",
+	"ScholieDistance":      "scholie",
+	"ScholieDistanceExact": "scholie",
+	"VersePositions":       "words",
+	"IBMModel1Lemma":       "ibm1",
+	"IBMModel1Text":        "ibm1",
+	"LemmaAssociation":     "goldStandard",
+}
+
+// validate checks the whole configuration so that a long run does not fail midway
+func (cfg experimentConfig) validate() error {
+	errs := []string{}
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Sprintf(format, args...))
+		}
+	}
+
+	files := map[string]string{
+		"words":        cfg.Data.Words,
+		"goldStandard": cfg.Data.GoldStandard,
+		"voc":          cfg.Data.Voc,
+		"equiv":        cfg.Data.Equiv,
+		"scholie":      cfg.Data.Scholie,
+		"ibm1":         cfg.Data.IBM1,
+	}
+	for name, path := range files {
+		_, err := os.Stat(path)
+		check(err == nil || (path == "" && name != "words" && name != "goldStandard"), "data.%s: cannot read %q", name, path)
+	}
+
+	sets := cfg.Features.featureSets()
+	check(len(sets) > 0, "features: no feature set to evaluate")
+	for _, set := range sets {
+		check(len(set) > 0, "features: empty feature set")
+		for _, name := range set {
+			info, err := aligner.LookupFeature(name)
+			check(err == nil, "features: %v", err)
+			for _, r := range info.Resources {
+				key := resourceData[r]
+				check(files[key] != "", "features: %s requires data.%s", name, key)
+			}
+		}
+	}
+
+	switch cfg.Split.Strategy {
+	case "head", "random":
+		check(cfg.Split.Train > 0 && cfg.Split.Train < 1, "split.train: must be in (0, 1), got %v", cfg.Split.Train)
+	case "kfold":
+		check(cfg.Split.Folds >= 2, "split.folds: must be at least 2, got %v", cfg.Split.Folds)
+	case "chant":
+	default:
+		check(false, "split.strategy: unknown strategy %q", cfg.Split.Strategy)
+	}
+
+	check(cfg.Workers >= 0, "workers: must not be negative, got %v", cfg.Workers)
+	check(cfg.Learner.N > 0, "learner.N: must be positive")
+	check(cfg.Learner.N0 >= 0 && cfg.Learner.N0 < cfg.Learner.N, "learner.N0: must be in [0, N)")
+	check(cfg.Learner.SubseqLen > 0, "learner.subseqLen: must be positive")
+
+	_, err := newAligner(cfg.Aligner)
+	check(err == nil, "aligner: %v", err)
+
+	check(cfg.Output.Log != "", "output.log: missing path")
+	if cfg.Output.Log != "" {
+		check(isDir(filepath.Dir(cfg.Output.Log)), "output.log: directory of %q does not exist", cfg.Output.Log)
+	}
+	if cfg.Output.Models != "" {
+		check(isDir(cfg.Output.Models), "output.models: %q is not a directory", cfg.Output.Models)
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid experiment configuration:\n\t%s", strings.Join(errs, "\n\t"))
+	}
+	return nil
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// defaultBeamWidth is the width of the beam aligner when not given in its name
+const defaultBeamWidth = 5
+
+// newAligner creates the aligner with the given name: "greek", "hungarian",
+// "monotonic", "itg" or "beam", optionally followed by the width of the beam
+// as in "beam:10"
+func newAligner(name string) (aligner.Aligner, error) {
+	kind, arg := name, ""
+	if i := strings.Index(name, ":"); i >= 0 {
+		kind, arg = name[:i], name[i+1:]
+	}
+	switch kind {
+	case "greek":
+		if arg == "" {
+			return aligner.NewGreekAligner(), nil
+		}
+	case "hungarian":
+		if arg == "" {
+			return aligner.NewHungarianAligner(), nil
+		}
+	case "monotonic":
+		if arg == "" {
+			return aligner.NewMonotonicAligner(), nil
+		}
+	case "itg":
+		if arg == "" {
+			return aligner.NewITGAligner(), nil
+		}
+	case "beam":
+		width := defaultBeamWidth
+		if arg != "" {
+			w, err := strconv.Atoi(arg)
+			if err != nil || w < 1 {
+				return nil, fmt.Errorf("invalid beam width %q", arg)
+			}
+			width = w
+		}
+		return aligner.NewBeamAligner(width), nil
+	}
+	return nil, fmt.Errorf("unknown aligner %q", name)
+}
+
+func experimentCmd(args []string) {
+	fs := flag.NewFlagSet("experiment", flag.ExitOnError)
+	configPath := fs.String("config", "configs/default.json", "path to the experiment configuration file")
+	check := fs.Bool("check", false, "only validate the configuration")
+	seed := fs.Int64("seed", 0, "seed of the random choices, overrides learner.seed of the configuration")
+	fs.Parse(args)
+
+	cfg, err := loadExperimentConfig(*configPath)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "seed" {
+			cfg.Learner.Seed = *seed
+		}
+	})
+	if err := cfg.validate(); err != nil {
+		log.Fatalln(err)
+	}
+	if *check {
+		fmt.Println("Configuration ", *configPath, " is valid")
+		return
+	}
+	runExperiment(cfg)
+}
+
+// experimentResult is the outcome of the test of a feature set over all the folds
+type experimentResult struct {
+	Features []string     `json:"features"`
+	Folds    []foldResult `json:"folds"`
+	Mean     metrics      `json:"mean"`
+	Std      metrics      `json:"std"`
+}
+
+// foldResult is the outcome of a feature set on a single train/test fold
+type foldResult struct {
+	Name    string    `json:"name"`
+	Weights []float64 `json:"weights"`
+	Metrics metrics   `json:"metrics"`
+}
+
+// experimentFold is a partition of the gold standard in a training and a test set
+type experimentFold struct {
+	Name        string
+	train, test []goldStandard
+}
+
+// folds partitions the gold standard, sorted by chant and verse, following the
+// split strategy. The random choices depend only on the seed.
+func (s experimentSplit) folds(gs []goldStandard, seed int64) ([]experimentFold, error) {
+	shuffled := append([]goldStandard{}, gs...)
+	if s.Strategy == "random" || s.Strategy == "kfold" {
+		shuffle(shuffled, rand.New(rand.NewSource(seed)))
+	}
+
+	switch s.Strategy {
+	case "head", "random":
+		splitIndex := int(s.Train * float64(len(shuffled)))
+		// the test set is never empty since Train < 1
+		if splitIndex == 0 {
+			return nil, fmt.Errorf("split %v of %d problems leaves an empty training set", s.Train, len(shuffled))
+		}
+		return []experimentFold{{Name: s.Strategy, train: shuffled[:splitIndex], test: shuffled[splitIndex:]}}, nil
+	case "kfold":
+		if s.Folds > len(shuffled) {
+			return nil, fmt.Errorf("cannot split %d problems in %d folds", len(shuffled), s.Folds)
+		}
+		folds := []experimentFold{}
+		for k := 0; k < s.Folds; k++ {
+			from, to := k*len(shuffled)/s.Folds, (k+1)*len(shuffled)/s.Folds
+			f := experimentFold{Name: fmt.Sprint("fold-", k+1)}
+			f.train = append(append(f.train, shuffled[:from]...), shuffled[to:]...)
+			f.test = sortedByID(shuffled[from:to])
+			folds = append(folds, f)
+		}
+		return folds, nil
+	case "chant":
+		chants := []string{}
+		byChant := map[string][]goldStandard{}
+		for _, g := range gs {
+			c := strings.SplitN(g.ID, ".", 2)[0]
+			if _, ok := byChant[c]; !ok {
+				chants = append(chants, c)
+			}
+			byChant[c] = append(byChant[c], g)
+		}
+		if len(chants) < 2 {
+			return nil, fmt.Errorf("leave-one-chant-out needs at least 2 chants, got %d", len(chants))
+		}
+		folds := []experimentFold{}
+		for _, c := range chants {
+			f := experimentFold{Name: "chant-" + c, test: byChant[c]}
+			for _, other := range chants {
+				if other != c {
+					f.train = append(f.train, byChant[other]...)
+				}
+			}
+			folds = append(folds, f)
+		}
+		return folds, nil
+	}
+	return nil, fmt.Errorf("unknown split strategy %q", s.Strategy)
+}
+
+// sortedByID returns a copy of gs sorted by chant and verse
+func sortedByID(gs []goldStandard) []goldStandard {
+	res := append([]goldStandard{}, gs...)
+	sort.Slice(res, func(i, j int) bool { return aligner.ProblemIDLess(res[i].ID, res[j].ID) })
+	return res
+}
+
+// runExperiment trains and tests every feature set of the configuration on each
+// fold, writing the results to the log file and a manifest next to it for each of them
+func runExperiment(cfg experimentConfig) []experimentResult {
+	if err := cfg.validate(); err != nil {
+		log.Fatalln(err)
+	}
+	paths := cfg.Data.paths()
+	inputs, err := paths.inputs()
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	ctx := loadAdditionalData(paths)
+
+	fmt.Println("Loading words database")
+	wordsDB, err := loadDB(*paths.words)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	ctx.LoadVerses(wordsDB)
+	fmt.Println("Loading gold standard")
+	gs := loadGoldStandard(*paths.goldStandard, wordsDB)
+
+	folds, err := cfg.Split.folds(gs, cfg.Learner.Seed)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	// the lemma association of each fold is learned from its training set only
+	associations := make([]*aligner.LemmaAssociation, len(folds))
+	for k, fold := range folds {
+		associations[k] = lemmaAssociation(fold.train)
+	}
+
+	createLogFile(cfg.Output.Log)
+	ar, _ := newAligner(cfg.Aligner)
+	params := cfg.Learner
+	res := []experimentResult{}
+	sets := cfg.Features.featureSets()
+	for idx, names := range sets {
+		model := aligner.NewModel(names, make([]float64, len(names)), params)
+		ff, _, err := model.Resolve()
+		if err != nil {
+			log.Fatalln(err)
+		}
+		fmt.Println(names)
+		alignAlg := func(p aligner.Problem, w []float64) *aligner.Alignment {
+			a, err := aligner.NewFromWordBags(p.From, p.To).Align(ar, ff, w, params.SubseqLen, ctx)
+			if err != nil {
+				log.Fatalln(err)
+			}
+			return a
+		}
+
+		r := experimentResult{Features: names}
+		report := newErrorReport()
+		verses := []verseResult{}
+		var elapsedLearn, elapsed time.Duration
+		totalTime := time.Now()
+		for k, fold := range folds {
+			model.Association = nil
+			if aligner.NeedsResource(names, "LemmaAssociation") {
+				model.Association = associations[k]
+			}
+			ctx.Set("LemmaAssociation", associations[k])
+			ctx.ResetCache()
+			fmt.Println("- Start learning process... ", idx+1, "/", len(sets), " fold ", fold.Name)
+			startLearn := time.Now()
+			w := learn(fold.train, params.N, params.N0, params.R0, params.R, ff, alignAlg, ctx, rand.New(rand.NewSource(params.Seed)))
+			fmt.Println("- Learning done ", w)
+			elapsedLearn += time.Since(startLearn)
+
+			start := time.Now()
+			fmt.Println("- Start alignment test")
+			results := evaluate(fold.test, cfg.Workers, ctx, ar, ff, w, params.SubseqLen)
+			fmt.Println("- End alignment test")
+			elapsed += time.Since(start)
+			r.Folds = append(r.Folds, foldResult{Name: fold.Name, Weights: w, Metrics: aggregateMetrics(results)})
+			for _, v := range results {
+				report.add(v.ID, v.Gold, v.Alignment)
+			}
+			verses = append(verses, results...)
+
+			if cfg.Output.Models != "" {
+				name := fmt.Sprintf("model-%d.json", idx+1)
+				if len(folds) > 1 {
+					name = fmt.Sprintf("model-%d-%d.json", idx+1, k+1)
+				}
+				model.Weights = w
+				model.Inputs = inputs
+				if err := model.Save(filepath.Join(cfg.Output.Models, name)); err != nil {
+					log.Fatalln(err)
+				}
+			}
+		}
+		ms := []metrics{}
+		for _, f := range r.Folds {
+			ms = append(ms, f.Metrics)
+		}
+		r.Mean, r.Std = meanStd(ms)
+		res = append(res, r)
+
+		appendResult(cfg.Output.Log, idx+1, r, elapsedLearn, elapsed, time.Since(totalTime))
+		if err := newRunManifest(cfg, inputs, r).save(resultPath(cfg.Output.Log, idx+1, "manifest.json")); err != nil {
+			log.Fatalln(err)
+		}
+		fmt.Print(report)
+		if err := report.save(resultPath(cfg.Output.Log, idx+1, "errors.json")); err != nil {
+			log.Fatalln(err)
+		}
+		if err := newVerseReport(verses).save(func(name string) string { return resultPath(cfg.Output.Log, idx+1, name) }); err != nil {
+			log.Fatalln(err)
+		}
+	}
+	return res
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"text/tabwriter"
+
+	aligner "github.com/szenzaro/iliad-aligner/aligner"
+)
+
+func explainCmd(args []string) {
+	fs := flag.NewFlagSet("explain", flag.ExitOnError)
+	af := addModelFlags(fs, "data/G44_I_III_HomPara.xlsx")
+	verse := fs.String("verse", "", "ID of the verse to explain, e.g. 1.5")
+	fs.Parse(args)
+
+	if *verse == "" {
+		log.Fatalln("missing -verse")
+	}
+
+	model, ctx, wordsDB := af.loadModel()
+	a, ok := af.alignWith(model, ctx, wordsDB, func(id string) bool { return id == *verse })[*verse]
+	if !ok {
+		log.Fatalln("verse ", *verse, " not found in ", *af.paths.words)
+	}
+	xs, err := a.Explain(model, ctx)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	printExplanations(os.Stdout, *verse, xs)
+}
+
+// printExplanations writes the score breakdown of each edit followed by the one
+// of the best alternative for the same words
+func printExplanations(out io.Writer, id string, xs []aligner.Explanation) {
+	fmt.Fprintf(out, "Verse %s: %d edits\n", id, len(xs))
+	for _, x := range xs {
+		fmt.Fprintf(out, "\n%s\n", describeEdits([]aligner.Edit{x.Edit}))
+		printBreakdown(out, x.Chosen)
+		if x.Alternative == nil {
+			fmt.Fprintln(out, "  no alternative for the same words")
+			continue
+		}
+		fmt.Fprintf(out, "  best alternative: %s (margin %g)\n", describeEdits(x.Alternative.Edits), x.Chosen.Score-x.Alternative.Score)
+		printBreakdown(out, *x.Alternative)
+	}
+}
+
+func printBreakdown(out io.Writer, b aligner.Breakdown) {
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "\tfeature\tvalue\tweight\tcontribution\t")
+	for _, f := range b.Features {
+		fmt.Fprintf(w, "\t%s\t%.4f\t%.4f\t%.4f\t\n", f.Feature, f.Value, f.Weight, f.Contribution)
+	}
+	fmt.Fprintf(w, "\tscore\t\t\t%.4f\t\n", b.Score)
+	w.Flush()
+}
+
+// describeEdits shows the type and the HOM and PARA words of each edit
+func describeEdits(edits []aligner.Edit) string {
+	res := ""
+	for i, e := range edits {
+		if i > 0 {
+			res += ", "
+		}
+		from, to := aligner.EditWords(e)
+		res += fmt.Sprintf("%s %s → %s", aligner.EditTypeName(e), wordTexts(from), wordTexts(to))
+	}
+	return res
+}
+
+func wordTexts(ws []aligner.Word) string {
+	if len(ws) == 0 {
+		return "-"
+	}
+	res := "["
+	for i, w := range ws {
+		if i > 0 {
+			res += " "
+		}
+		res += w.Text
+	}
+	return res + "]"
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	aligner "github.com/szenzaro/iliad-aligner/aligner"
+)
+
+func exportCmd(args []string) {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	af := addAlignFlags(fs)
+	outDir := fs.String("o", "out/viewer", "path to the output directory")
+	per := fs.String("per", "chant", "granularity of the output files: chant, verse or corpus")
+	fs.Parse(args)
+
+	if *per != "chant" && *per != "verse" && *per != "corpus" {
+		log.Fatalln("unknown granularity ", *per)
+	}
+	if err := os.MkdirAll(*outDir, 0755); err != nil {
+		log.Fatalln(err)
+	}
+
+	files, err := writeViewerFiles(*outDir, *per, af.alignAll())
+	if err != nil {
+		log.Fatalln(err)
+	}
+	fmt.Println("Exported ", len(files), " files to ", *outDir)
+}
+
+// writeViewerFiles merges the alignments by chant, verse or for the whole corpus
+// and writes a viewer JSON file for each group
+func writeViewerFiles(dir, per string, alignments map[string]*aligner.Alignment) ([]string, error) {
+	groups := map[string][]*aligner.Alignment{}
+	for id, a := range alignments {
+		name := "corpus"
+		switch per {
+		case "chant":
+			name = "chant-" + strings.SplitN(id, ".", 2)[0]
+		case "verse":
+			name = "verse-" + id
+		}
+		groups[name] = append(groups[name], a)
+	}
+
+	files := []string{}
+	for name, as := range groups {
+		d, err := json.MarshalIndent(aligner.NewViewerAlignment(aligner.MergeAlignments(as...)), "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		path := filepath.Join(dir, name+".json")
+		if err := ioutil.WriteFile(path, d, 0644); err != nil {
+			return nil, err
+		}
+		files = append(files, path)
+	}
+	return files, nil
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"strings"
+
+	aligner "github.com/szenzaro/iliad-aligner/aligner"
+)
+
+func ibm1Cmd(args []string) {
+	fs := flag.NewFlagSet("ibm1", flag.ExitOnError)
+	words := fs.String("w", "data/G44_HOM.xlsx,data/G44_PARA.xlsx", "comma separated paths of the xlsx files containing the verses to train on")
+	iterations := fs.Int("iterations", 5, "number of EM iterations")
+	outPath := fs.String("o", "out/ibm1.json", "path to the output file")
+	fs.Parse(args)
+
+	fmt.Println("Loading words database")
+	wordsDB, err := aligner.LoadDB(strings.Split(*words, ","))
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	models := []*aligner.IBMModel1{}
+	for _, field := range []string{"Lemma", "Text"} {
+		fmt.Println("Training IBM Model 1 on the", strings.ToLower(field)+"s")
+		m, err := aligner.TrainIBMModel1(wordsDB, field, *iterations)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		models = append(models, m)
+	}
+	if err := aligner.SaveIBMModels(*outPath, models); err != nil {
+		log.Fatalln(err)
+	}
+	fmt.Println("IBM Model 1 saved to ", *outPath)
+}
@@ -1,15 +1,16 @@
 package main
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"math"
 	"math/rand"
 	"os"
-	"reflect"
 	"regexp"
-	"runtime"
 	"strings"
 	"time"
 
@@ -27,139 +28,160 @@ type goldStandard struct {
 }
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "train":
+			trainCmd(os.Args[2:])
+			return
+		case "align":
+			alignCmd(os.Args[2:])
+			return
+		case "export":
+			exportCmd(os.Args[2:])
+			return
+		case "experiment":
+			experimentCmd(os.Args[2:])
+			return
+		case "diff":
+			diffCmd(os.Args[2:])
+			return
+		case "explain":
+			explainCmd(os.Args[2:])
+			return
+		case "replay":
+			replayCmd(os.Args[2:])
+			return
+		case "ibm1":
+			ibm1Cmd(os.Args[2:])
+			return
+		case "features":
+			featuresCmd()
+			return
+		}
+	}
+
 	defer profile.Start(profile.MemProfile).Stop()
 
-	wordsPath := flag.String("w", "data/G44_I_III_HomPara.xlsx", "path to the xlsx file containing all the words")
-	tsPath := flag.String("ts", "data/G44_ALI.tmx", "path to the tmx file containing the alignment for the words in the DB")
-	vocPath := flag.String("voc", "data/Vocabulaire_Genavensis.xlsx", "path to the vocabulary xlsx file")
-	equivPath := flag.String("equiv", "data/Lexique Homer termes Equivalents 1-3.xlsx", "path to the equivalent terms xlsx file")
-	scholiePath := flag.String("sch", "data/scholied.json", "path to the scholie JSON file")
-	logPath := flag.String("log", "out/test.log", "path to log file")
+	cfg := defaultExperimentConfig()
+	flag.StringVar(&cfg.Data.Words, "w", cfg.Data.Words, "path to the xlsx file containing all the words")
+	flag.StringVar(&cfg.Data.GoldStandard, "ts", cfg.Data.GoldStandard, "path to the tmx file containing the alignment for the words in the DB")
+	flag.StringVar(&cfg.Data.Voc, "voc", cfg.Data.Voc, "path to the vocabulary xlsx file, empty to skip it")
+	flag.StringVar(&cfg.Data.Equiv, "equiv", cfg.Data.Equiv, "path to the equivalent terms xlsx file, empty to skip it")
+	flag.StringVar(&cfg.Data.Scholie, "sch", cfg.Data.Scholie, "path to the scholie JSON file, empty to skip it")
+	flag.StringVar(&cfg.Data.IBM1, "ibm1", cfg.Data.IBM1, "path to the IBM Model 1 file written by the ibm1 command, empty to skip it")
+	flag.StringVar(&cfg.Output.Log, "log", cfg.Output.Log, "path to log file")
+	flag.IntVar(&cfg.Workers, "workers", cfg.Workers, "number of verses aligned in parallel during the test, 0 uses all the CPUs")
+	flag.Int64Var(&cfg.Learner.Seed, "seed", cfg.Learner.Seed, "seed of the random choices of the split and of the training")
 
 	flag.Parse()
-	// TODO: check flags for errors or empty strings
 
-	aligner.AdditionalData = map[string]interface{}{}
+	runExperiment(cfg)
+}
 
-	fmt.Println("Loading vocabulary")
-	_, err := aligner.LoadVoc(*vocPath, "VocDistance")
-	if err != nil {
-		log.Fatalln(err)
+func featuresCmd() {
+	for _, name := range aligner.FeatureNames() {
+		info, _ := aligner.LookupFeature(name)
+		fmt.Printf("%s\t[%v, %v]\t%s", name, info.Min, info.Max, info.Description)
+		if len(info.Resources) > 0 {
+			fmt.Printf(" (requires %s)", strings.Join(info.Resources, ", "))
+		}
+		fmt.Println()
 	}
+}
 
-	fmt.Println("Loading equivalence terms")
-	_, err = aligner.LoadVoc(*equivPath, "EquivTermDistance")
-	if err != nil {
-		log.Fatalln(err)
+// dataPaths holds the paths of the input files shared by all the commands
+type dataPaths struct {
+	words, goldStandard, voc, equiv, scholie, ibm1 *string
+}
+
+func addDataFlags(fs *flag.FlagSet) dataPaths {
+	paths := dataPaths{
+		words:        fs.String("w", "data/G44_I_III_HomPara.xlsx", "path to the xlsx file containing all the words"),
+		goldStandard: fs.String("ts", "data/G44_ALI.tmx", "path to the tmx file containing the alignment for the words in the DB"),
 	}
+	addResourceFlags(fs, &paths)
+	return paths
+}
 
-	fmt.Println("Loading scholie")
-	_, err = aligner.LoadScholie(*scholiePath)
-	if err != nil {
-		log.Fatalln(err)
+func addResourceFlags(fs *flag.FlagSet, paths *dataPaths) {
+	paths.voc = fs.String("voc", "data/Vocabulaire_Genavensis.xlsx", "path to the vocabulary xlsx file, empty to skip it")
+	paths.equiv = fs.String("equiv", "data/Lexique Homer termes Equivalents 1-3.xlsx", "path to the equivalent terms xlsx file, empty to skip it")
+	paths.scholie = fs.String("sch", "data/scholied.json", "path to the scholie JSON file, empty to skip it")
+	paths.ibm1 = fs.String("ibm1", "", "path to the IBM Model 1 file written by the ibm1 command, empty to skip it")
+}
+
+// loadAdditionalData creates a context with the resources whose path is not empty
+func loadAdditionalData(paths dataPaths) *aligner.Context {
+	ctx := aligner.NewContext()
+
+	if *paths.voc != "" {
+		fmt.Println("Loading vocabulary")
+		if _, err := ctx.LoadVoc(*paths.voc, "VocDistance"); err != nil {
+			log.Fatalln(err)
+		}
 	}
 
-	fmt.Println("Loading words database")
-	wordsDB, err := loadDB(*wordsPath)
-	if err != nil {
-		log.Fatalln(err)
+	if *paths.equiv != "" {
+		fmt.Println("Loading equivalence terms")
+		if _, err := ctx.LoadVoc(*paths.equiv, "EquivTermDistance"); err != nil {
+			log.Fatalln(err)
+		}
 	}
-	fmt.Println("Loading gold standard")
-	gs := loadGoldStandard(*tsPath, wordsDB)
-
-	splitIndex := 3 * len(gs) / 10 // about 30%
-	// splitIndex := 25 * len(gs) / 100 // about 25%
-	trainingSet := gs[:splitIndex]
-	testSet := gs[splitIndex:]
-
-	features := []aligner.Feature{
-		// aligner.EditType,
-		// aligner.LexicalSimilarity,
-		// aligner.LemmaDistance,
-		aligner.TextualDistance,
-		aligner.TagDistance,
-		aligner.VocDistance,
-		aligner.ScholieDistance,
-		aligner.EqEquivTermDistance,
-		// aligner.MaxDistance,
-	}
-
-	tests := getTests(features)
-
-	createLogFile(*logPath)
-	ar := aligner.NewGreekAligner()
-	subseqLen := 1
-	for idx, ff := range tests {
-		fmt.Println(getFeatureNames(ff))
-		aligner.ResetCache()
-		alignAlg := func(p aligner.Problem, w []float64) *aligner.Alignment {
-			a, err := aligner.NewFromWordBags(p.From, p.To).Align(ar, ff, w, subseqLen, aligner.AdditionalData)
-			if err != nil {
-				log.Fatalln(err)
-			}
-			return a
+
+	if *paths.scholie != "" {
+		fmt.Println("Loading scholie")
+		if _, err := ctx.LoadScholie(*paths.scholie); err != nil {
+			log.Fatalln(err)
+		}
+		if _, err := ctx.LoadScholieDict(*paths.scholie); err != nil {
+			log.Fatalln(err)
 		}
+	}
 
-		fmt.Println("- Start learning process... ", idx+1, "/", len(tests))
-		startLearn := time.Now()
-		totalTime := time.Now()
-		// w := []float64{0.2956361042981355, 0.060325626401096885, 0.033855873309357465, 0.024419617049442562, 0.8058173377380647, 0.004187020307669374, 0.1931506936628718}
-		w := learn(trainingSet, 50, 10, 1.0, 0.8, ff, alignAlg, aligner.AdditionalData)
-		// w := learn(trainingSet[:10], 2, 1, 1.0, 0.8, ff, alignAlg, aligner.AdditionalData)
-		fmt.Println("- Learning done ", w)
-		elapsedLearn := time.Since(startLearn)
-
-		totalAcc := 0.0
-		totalEditAcc := 0.0
-		start := time.Now()
-		fmt.Println("- Start alignment test")
-		for i, p := range testSet {
-			aligner.ResetCache()
-			fmt.Println(p.ID, " ", i+1, "/", len(testSet))
-			a := aligner.NewFromWordBags(p.p.From, p.p.To)
-			res, err := a.Align(ar, ff, w, subseqLen, aligner.AdditionalData)
-			if err != nil {
-				log.Fatalln(err)
-			}
-			acc := aligner.ScoreAccuracy(p.a, res, ff, w, aligner.AdditionalData)
-			totalAcc += acc
-			editAcc := res.EditsAccuracy(p.a)
-			totalEditAcc += editAcc
-			// fmt.Println()
-			// fmt.Println("Expected: ", p.a)
-			// fmt.Println("Got: ", res)
-			// fmt.Println("with accuracy ", acc)
-			// fmt.Println("with edit accuracy ", editAcc)
-			// fmt.Println()
+	if paths.ibm1 != nil && *paths.ibm1 != "" {
+		fmt.Println("Loading IBM Model 1")
+		if _, err := ctx.LoadIBMModels(*paths.ibm1); err != nil {
+			log.Fatalln(err)
 		}
-		fmt.Println("- End alignment test")
-		elapsed := time.Since(start)
-		elapedTotal := time.Since(totalTime)
-		// fmt.Println("Learned w: ", w)
-		// fmt.Println("Split percentage: ", float64(splitIndex)/float64(len(gs)))
-		// fmt.Println("Learn time needed: ", elapsedLearn)
-		// fmt.Println("Alignment time needed: ", elapsed)
-		// fmt.Println("With functions:")
-		// for _, f := range ff {
-		// 	fmt.Println("\t- ", getFunctionName(f))
-		// }
-		totalAccuracy := totalAcc / float64(len(testSet))
-		// fmt.Println("Total accuracy: ", totalAccuracy)
-		totalEditAccuracy := totalEditAcc / float64(len(testSet))
-		// fmt.Println("Total edit accuracy: ", totalEditAccuracy)
-
-		appendResult(*logPath, idx+1, ff, w, elapsedLearn, elapsed, elapedTotal, totalAccuracy, totalEditAccuracy)
 	}
+	return ctx
+}
 
+// inputs returns the paths and hashes of the input files
+func (p dataPaths) inputs() (map[string]aligner.Input, error) {
+	files := map[string]*string{
+		"words":        p.words,
+		"goldStandard": p.goldStandard,
+		"voc":          p.voc,
+		"equiv":        p.equiv,
+		"scholie":      p.scholie,
+		"ibm1":         p.ibm1,
+	}
+	res := map[string]aligner.Input{}
+	for k, path := range files {
+		if path == nil || *path == "" {
+			continue
+		}
+		h, err := fileHash(*path)
+		if err != nil {
+			return nil, err
+		}
+		res[k] = aligner.Input{Path: *path, SHA256: h}
+	}
+	return res, nil
 }
 
-func getFeatureNames(ff []aligner.Feature) []string {
-	d := []string{}
-	for _, f := range ff {
-		n := strings.Split(getFunctionName(f), ".")
-		d = append(d, n[len(n)-1])
+func fileHash(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
 	}
-	return d
+	return hex.EncodeToString(h.Sum(nil)), nil
 }
 
 func createLogFile(path string) {
@@ -170,55 +192,52 @@ func createLogFile(path string) {
 	}
 	defer file.Close()
 
-	header := fmt.Sprintln("Test Number\tFeatures\tEdit Accuracy\tScore Accuracy\tTotal Time\tLearn Time\tAlignment Time\tWeights")
+	header := "Test Number\tFeatures\tFolds"
+	for _, name := range metricNames {
+		header += "\t" + name + "\t" + name + " Std"
+	}
+	header += "\tTotal Time\tLearn Time\tAlignment Time\tWeights\n"
 	file.WriteString(header)
 	fmt.Println("Log file Created Successfully", path)
 }
 
-func appendResult(path string, idx int, ff []aligner.Feature, w []float64, learnTime, alignmentTime, totalTime time.Duration, scoreAccuracy, editAccuracy float64) {
+func appendResult(path string, idx int, r experimentResult, learnTime, alignmentTime, totalTime time.Duration) {
 	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 	if err != nil {
 		log.Fatalln(err)
 	}
 	defer f.Close()
 
-	names := getFeatureNames(ff)
-
-	text := fmt.Sprintln(
-		idx, "\t",
-		names, "\t",
-		editAccuracy, "\t",
-		scoreAccuracy, "\t",
-		totalTime, "\t",
-		learnTime, "\t",
-		alignmentTime, "\t",
-		w,
-	)
-
-	if _, err := f.WriteString(text); err != nil {
-		log.Println(err)
+	weights := []vectors.Vector{}
+	for _, fold := range r.Folds {
+		weights = append(weights, fold.Weights)
 	}
-}
-
-func getTests(ff []aligner.Feature) [][]aligner.Feature {
-	tt := [][]aligner.Feature{}
+	columns := []interface{}{idx, r.Features, len(r.Folds)}
+	mean, std := r.Mean.fields(), r.Std.fields()
+	for i := range mean {
+		columns = append(columns, *mean[i], *std[i])
+	}
+	columns = append(columns, totalTime, learnTime, alignmentTime, vectors.Avg(weights))
 
-	for _, v := range powerSet(ff) {
-		if len(v) == 0 {
-			continue
+	text := ""
+	for i, c := range columns {
+		if i > 0 {
+			text += "\t"
 		}
-		tt = append(tt, v)
+		text += fmt.Sprint(c)
+	}
+	if _, err := f.WriteString(text + "\n"); err != nil {
+		log.Println(err)
 	}
-	return tt
 }
 
-func powerSet(original []aligner.Feature) [][]aligner.Feature {
+func powerSet(original []string) [][]string {
 	powerSetSize := int(math.Pow(2, float64(len(original))))
-	result := make([][]aligner.Feature, 0, powerSetSize)
+	result := make([][]string, 0, powerSetSize)
 
 	var index int
 	for index < powerSetSize {
-		var subSet []aligner.Feature
+		var subSet []string
 
 		for j, elem := range original {
 			if index&(1<<uint(j)) > 0 {
@@ -231,8 +250,13 @@ func powerSet(original []aligner.Feature) [][]aligner.Feature {
 	return result
 }
 
-func getFunctionName(i interface{}) string {
-	return runtime.FuncForPC(reflect.ValueOf(i).Pointer()).Name()
+// lemmaAssociation counts the lemmas linked by the alignments of the gold standard problems
+func lemmaAssociation(gs []goldStandard) *aligner.LemmaAssociation {
+	gold := []*aligner.Alignment{}
+	for _, g := range gs {
+		gold = append(gold, g.a)
+	}
+	return aligner.NewLemmaAssociation(gold, aligner.DefaultAssociationSmoothing)
 }
 
 func loadGoldStandard(path string, words aligner.DB) []goldStandard {
@@ -257,9 +281,14 @@ func loadGoldStandard(path string, words aligner.DB) []goldStandard {
 		}
 	}
 
-	//to array
-	gs := []goldStandard{}
+	//to array, sorted by chant and verse
+	ids := []string{}
 	for k := range problems {
+		ids = append(ids, k)
+	}
+	aligner.SortProblemIDs(ids)
+	gs := []goldStandard{}
+	for _, k := range ids {
 		gs = append(gs, problems[k])
 	}
 	return gs
@@ -271,28 +300,41 @@ func learn(
 	R0, r float64,
 	featureFunctions []aligner.Feature,
 	alignAlg func(aligner.Problem, []float64) *aligner.Alignment,
-	data map[string]interface{},
+	ctx *aligner.Context,
+	rnd *rand.Rand,
 ) []float64 {
 	w := make(vectors.Vector, len(featureFunctions))
 	for i := range w {
 		w[i] = 1.0
 	}
 	epochs := []vectors.Vector{}
+	// the problems are shuffled in a copy, so that every call with the same seed
+	// sees them in the same order
+	trainingProblems = append([]goldStandard{}, trainingProblems...)
 	n := len(trainingProblems)
+	// each problem is learned with the lemma association of the others, the full
+	// one is put back at the end for the test
+	association, hasAssociation := ctx.Resource("LemmaAssociation")
+	if hasAssociation {
+		defer ctx.Set("LemmaAssociation", association)
+	}
 	R := R0
 	// start := time.Now()
 	for i := 0; i < N; i++ {
 		// start := time.Now()
 		R = r * R
-		shuffle(trainingProblems)
+		shuffle(trainingProblems, rnd)
 		for j := 0; j < n; j++ {
 			fmt.Println(j+1, "/", n, " -- of ", i+1, "/", N, " ", trainingProblems[j].ID)
-			aligner.ResetCache()
+			ctx.ResetCache()
+			if hasAssociation {
+				ctx.Set("LemmaAssociation", association.(*aligner.LemmaAssociation).Without(trainingProblems[j].a))
+			}
 			// ss := time.Now()
 			Ej := alignAlg(trainingProblems[j].p, w)
 			diff := vectors.Diff(
-				aligner.Phi(trainingProblems[j].a, featureFunctions, data),
-				aligner.Phi(Ej, featureFunctions, data)) // phi(Ej) - phi(Êj)
+				aligner.Phi(trainingProblems[j].a, featureFunctions, ctx),
+				aligner.Phi(Ej, featureFunctions, ctx)) // phi(Ej) - phi(Êj)
 			w = vectors.Sum(w, diff.Scale(R))
 			// fmt.Println("finished in: ", time.Since(ss))
 		}
@@ -325,6 +367,7 @@ func loadDB(path string) (aligner.DB, error) {
 				ID:     aligner.GetWordID(row.Cells[2].Value, row.Cells[0].Value), // Source.ID // TODO check id bis
 				Verse:  row.Cells[10].Value,
 				Chant:  row.Cells[3].Value,
+				Form:   strings.TrimSpace(row.Cells[15].Value),
 				Text:   row.Cells[19].Value, // Normalized text
 				Lemma:  row.Cells[20].Value,
 				Tag:    row.Cells[21].Value,
@@ -360,15 +403,14 @@ func getProblems(words aligner.DB) map[string]goldStandard {
 	return data
 }
 
-func shuffle(vals []goldStandard) {
-	r := rand.New(rand.NewSource(time.Now().Unix()))
+func shuffle(vals []goldStandard, r *rand.Rand) {
 	for n := len(vals); n > 0; n-- {
 		randIndex := r.Intn(n)
 		vals[n-1], vals[randIndex] = vals[randIndex], vals[n-1]
 	}
 }
 
-func equal(v, w aligner.Word) bool { return v.Text == w.Text }
+func equal(v, w aligner.Word) bool { return aligner.SameText(v, w) }
 
 func getID(v string, r *regexp.Regexp) string {
 	submatch := r.FindStringSubmatch(v)
@@ -408,7 +450,7 @@ func getEditFromTu(from, to []aligner.Word) aligner.Edit {
 func canGetEdit(from, to []aligner.Word) bool {
 	isIns := len(from) == 0 && len(to) == 1
 	isDel := len(from) == 1 && len(to) == 0
-	isEq := len(from) == 1 && len(to) == 1 && from[0].Text == to[0].Text
+	isEq := len(from) == 1 && len(to) == 1 && equal(from[0], to[0])
 	notEmpty := len(from) > 0 && len(to) > 0
 	return isIns || isDel || isEq || notEmpty
 }
@@ -1,7 +1,15 @@
 package main
 
 import (
+	"fmt"
+	"io/ioutil"
 	"log"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 
 	aligner "github.com/szenzaro/iliad-aligner/aligner"
@@ -45,3 +53,560 @@ func TestEditAccuracy(t *testing.T) {
 		}
 	}
 }
+
+func TestTMXRoundTrip(t *testing.T) {
+	wordsDB, err := loadDB("data/G44_I_III_HomPara.xlsx")
+	if err != nil {
+		log.Fatalln(err)
+	}
+	gs := loadGoldStandard("data/G44_ALI.tmx", wordsDB)
+
+	alignments := map[string]*aligner.Alignment{}
+	for _, k := range gs {
+		if len(k.a.Edits()) > 0 {
+			alignments[k.ID] = k.a
+		}
+	}
+
+	dir, err := ioutil.TempDir("", "tmx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "out.tmx")
+	if err := writeTMX(path, alignments); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, k := range loadGoldStandard(path, wordsDB) {
+		expected, ok := alignments[k.ID]
+		if !ok {
+			if len(k.a.Edits()) > 0 {
+				t.Error("Unexpected edits for ", k.ID)
+			}
+			continue
+		}
+		if len(k.a.Edits()) != len(expected.Edits()) || k.a.EditsAccuracy(expected) != 1.0 || expected.EditsAccuracy(k.a) != 1.0 {
+			t.Error("Expected ", expected, " for ", k.ID, " got ", k.a)
+		}
+	}
+
+	bad := aligner.NewFromEdits(&aligner.Ins{W: aligner.Word{ID: "PARA.x", Source: "PARA"}})
+	if err := writeTMX(filepath.Join(dir, "bad.tmx"), map[string]*aligner.Alignment{"1.1": bad}); err == nil {
+		t.Error("Expected error for a word without a numeric ID")
+	}
+}
+
+func TestTMXRoundTripEdits(t *testing.T) {
+	word := func(source, id, form, text string) aligner.Word {
+		return aligner.Word{ID: aligner.GetWordID(source, id), Form: form, Text: text, Chant: "1", Verse: "1", Source: source}
+	}
+	hom := []aligner.Word{word("HOM", "1", "Μῆνιν", "μῆνιν"), word("HOM", "2", "θεά,", "θεά,"), word("HOM", "3", "ἄειδε", "ἄειδε")}
+	para := []aligner.Word{word("PARA", "1", "Τὴν", "τὴν"), word("PARA", "2", "ὀργὴν", "ὀργὴν"), word("PARA", "3", "θεά·", "θεά"), word("PARA", "4", "εἰπὲ", "εἰπὲ")}
+	db := aligner.DB{}
+	for _, w := range append(append([]aligner.Word{}, hom...), para...) {
+		db[w.ID] = w
+	}
+	// an Eq of texts differing by their punctuation and a Sub of one HOM word to two PARA words
+	a := aligner.NewFromEdits(
+		&aligner.Sub{From: []aligner.Word{hom[0]}, To: []aligner.Word{para[0], para[1]}},
+		&aligner.Eq{From: hom[1], To: para[2]},
+		&aligner.Sub{From: []aligner.Word{hom[2]}, To: []aligner.Word{para[3]}},
+	)
+
+	dir, err := ioutil.TempDir("", "tmx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "out.tmx")
+	if err := writeTMX(path, map[string]*aligner.Alignment{"1.1": a}); err != nil {
+		t.Fatal(err)
+	}
+	d, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, token := range []string{"Μῆνιν_(1_1)_{1-0}", "θεά·_(1_1)_{3-0}"} {
+		if !strings.Contains(string(d), token) {
+			t.Error("Expected the surface form token ", token)
+		}
+	}
+
+	gs := loadGoldStandard(path, db)
+	if len(gs) != 1 || gs[0].ID != "1.1" {
+		t.Fatal("Expected the verse 1.1 got ", gs)
+	}
+	got := gs[0].a
+	if len(got.Edits()) != len(a.Edits()) || got.EditsAccuracy(a) != 1 || a.EditsAccuracy(got) != 1 {
+		t.Error("Expected ", a, " got ", got)
+	}
+
+	same := aligner.NewFromEdits(&aligner.Sub{From: []aligner.Word{hom[1]}, To: []aligner.Word{para[2]}})
+	if err := writeTMX(path, map[string]*aligner.Alignment{"1.1": same}); err == nil {
+		t.Error("Expected error for a Sub that would be read as an Eq")
+	}
+}
+
+func TestExperimentConfigValidate(t *testing.T) {
+	cfg, err := loadExperimentConfig("configs/default.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.validate(); err != nil {
+		t.Error("Expected valid default configuration got ", err)
+	}
+	if n := len(cfg.Features.featureSets()); n != 31 {
+		t.Error("Expected 31 feature sets got ", n)
+	}
+
+	tt := []struct {
+		name   string
+		change func(*experimentConfig)
+	}{
+		{name: "missing words", change: func(c *experimentConfig) { c.Data.Words = "data/missing.xlsx" }},
+		{name: "unknown feature", change: func(c *experimentConfig) { c.Features = experimentFeatures{Sets: [][]string{{"Unknown"}}} }},
+		{name: "no feature sets", change: func(c *experimentConfig) { c.Features = experimentFeatures{} }},
+		{name: "split", change: func(c *experimentConfig) { c.Split.Train = 1.5 }},
+		{name: "strategy", change: func(c *experimentConfig) { c.Split.Strategy = "unknown" }},
+		{name: "folds", change: func(c *experimentConfig) { c.Split = experimentSplit{Strategy: "kfold", Folds: 1} }},
+		{name: "N0", change: func(c *experimentConfig) { c.Learner.N0 = c.Learner.N }},
+		{name: "aligner", change: func(c *experimentConfig) { c.Aligner = "unknown" }},
+		{name: "aligner", change: func(c *experimentConfig) { c.Aligner = "beam:0" }},
+		{name: "aligner", change: func(c *experimentConfig) { c.Aligner = "itg:2" }},
+		{name: "log directory", change: func(c *experimentConfig) { c.Output.Log = "missing/test.log" }},
+		{name: "missing resource", change: func(c *experimentConfig) { c.Data.Voc = "" }},
+		{name: "workers", change: func(c *experimentConfig) { c.Workers = -1 }},
+	}
+	for _, v := range tt {
+		c := defaultExperimentConfig()
+		v.change(&c)
+		if err := c.validate(); err == nil {
+			t.Error("Expected error for ", v.name)
+		}
+	}
+}
+
+func TestForEachParallel(t *testing.T) {
+	ctx := aligner.NewContext()
+	for _, workers := range []int{0, 1, 4} {
+		visited := make([]int, 100)
+		forEachParallel(len(visited), workers, ctx, func(i int, c *aligner.Context) {
+			if c == ctx {
+				t.Error("Expected a forked context")
+			}
+			visited[i]++
+		})
+		for i, v := range visited {
+			if v != 1 {
+				t.Error("Expected index ", i, " to be visited once with ", workers, " workers, got ", v)
+			}
+		}
+	}
+}
+
+func TestForEachParallelWorkers(t *testing.T) {
+	ctx := aligner.NewContext()
+	ctx.SetWorkers(4)
+	tt := []struct {
+		workers int
+		inner   int
+	}{
+		{workers: 1, inner: 4},
+		{workers: 3, inner: 1},
+	}
+	for _, k := range tt {
+		forEachParallel(10, k.workers, ctx, func(i int, c *aligner.Context) {
+			if w := c.Workers(); w != k.inner {
+				t.Error("Expected ", k.inner, " scoring workers with ", k.workers, " workers, got ", w)
+			}
+		})
+	}
+	if w := ctx.Workers(); w != 4 {
+		t.Error("Expected 4 scoring workers in the parent context got ", w)
+	}
+}
+
+func TestEvaluateWorkers(t *testing.T) {
+	wordsDB, err := loadDB("data/G44_I_III_HomPara.xlsx")
+	if err != nil {
+		log.Fatalln(err)
+	}
+	gs := sortedByID(loadGoldStandard("data/G44_ALI.tmx", wordsDB))[:12]
+	ff := []aligner.Feature{aligner.TextualDistance, aligner.TagDistance}
+	w := []float64{1, 1}
+	ar, err := newAligner("greek")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := aligner.NewContext()
+	expected := evaluate(gs, 1, ctx, ar, ff, w, 2)
+	for _, workers := range []int{0, 4} {
+		results := evaluate(gs, workers, ctx, ar, ff, w, 2)
+		if len(results) != len(expected) {
+			t.Fatal("Expected ", len(expected), " results with ", workers, " workers got ", len(results))
+		}
+		for i, r := range results {
+			e := expected[i]
+			if r.ID != e.ID || r.Links != e.Links || r.EditAccuracy != e.EditAccuracy || r.ScoreAccuracy != e.ScoreAccuracy {
+				t.Error("Expected ", e.ID, " ", e.metrics(), " with ", workers, " workers got ", r.ID, " ", r.metrics())
+			}
+			if r.Alignment.EditsAccuracy(e.Alignment) != 1 || e.Alignment.EditsAccuracy(r.Alignment) != 1 {
+				t.Error("Expected the same alignment of ", e.ID, " with ", workers, " workers")
+			}
+		}
+	}
+}
+
+func TestLearnLeavesVerseOut(t *testing.T) {
+	wordsDB, err := loadDB("data/G44_I_III_HomPara.xlsx")
+	if err != nil {
+		log.Fatalln(err)
+	}
+	gs := sortedByID(loadGoldStandard("data/G44_ALI.tmx", wordsDB))[:4]
+	full := lemmaAssociation(gs)
+	ctx := aligner.NewContext()
+	ctx.Set("LemmaAssociation", full)
+
+	seen := map[string]bool{}
+	alignAlg := func(p aligner.Problem, w []float64) *aligner.Alignment {
+		for i, g := range gs {
+			if !reflect.DeepEqual(g.p, p) {
+				continue
+			}
+			others := append(append([]goldStandard{}, gs[:i]...), gs[i+1:]...)
+			r, _ := ctx.Resource("LemmaAssociation")
+			if expected := lemmaAssociation(others); !reflect.DeepEqual(r, expected) {
+				t.Error("Expected the association without ", g.ID, " got ", r)
+			}
+			seen[g.ID] = true
+			return g.a
+		}
+		t.Fatal("Unexpected problem ", p)
+		return nil
+	}
+	learn(gs, 1, 0, 0.5, 0.9, []aligner.Feature{aligner.LemmaDice}, alignAlg, ctx, rand.New(rand.NewSource(1)))
+	if len(seen) != len(gs) {
+		t.Error("Expected ", len(gs), " verses learned got ", len(seen))
+	}
+	if r, _ := ctx.Resource("LemmaAssociation"); r != full {
+		t.Error("Expected the full association after learning got ", r)
+	}
+}
+
+func TestShuffleSeed(t *testing.T) {
+	ids := func(seed int64) []string {
+		gs := []goldStandard{}
+		for i := 1; i <= 20; i++ {
+			gs = append(gs, goldStandard{ID: fmt.Sprint("1.", i)})
+		}
+		shuffle(gs, rand.New(rand.NewSource(seed)))
+		res := []string{}
+		for _, g := range gs {
+			res = append(res, g.ID)
+		}
+		return res
+	}
+	if a, b := ids(3), ids(3); !reflect.DeepEqual(a, b) {
+		t.Error("Expected ", a, " got ", b)
+	}
+	if a, b := ids(3), ids(4); reflect.DeepEqual(a, b) {
+		t.Error("Expected different orders with different seeds got ", a)
+	}
+}
+
+func TestRunManifest(t *testing.T) {
+	dir, err := ioutil.TempDir("", "manifest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := resultPath(filepath.Join(dir, "test.log"), 2, "manifest.json")
+	if expected := filepath.Join(dir, "test-2.manifest.json"); path != expected {
+		t.Error("Expected ", expected, " got ", path)
+	}
+
+	cfg := defaultExperimentConfig()
+	cfg.Learner.Seed = 42
+	res := experimentResult{
+		Features: []string{"TagDistance"},
+		Folds:    []foldResult{{Name: "head", Weights: []float64{0.1234567890123}, Metrics: metrics{ScoreAccuracy: 0.9, EditAccuracy: 0.7}}},
+		Mean:     metrics{ScoreAccuracy: 0.9, EditAccuracy: 0.7},
+	}
+	m := newRunManifest(cfg, map[string]aligner.Input{"words": {Path: "words.xlsx", SHA256: "abc"}}, res)
+	if err := m.save(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := loadManifest(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(loaded, m) {
+		t.Error("Expected ", m, " got ", loaded)
+	}
+	if sets := loaded.Config.Features.featureSets(); len(sets) != 1 || !reflect.DeepEqual(sets[0], res.Features) {
+		t.Error("Expected only the feature set ", res.Features, " got ", sets)
+	}
+	if loaded.Seed != 42 || loaded.Config.Learner.Seed != 42 {
+		t.Error("Expected seed 42 got ", loaded.Seed, " ", loaded.Config.Learner.Seed)
+	}
+}
+
+func TestExperimentFolds(t *testing.T) {
+	gs := []goldStandard{}
+	for c := 1; c <= 3; c++ {
+		for v := 1; v <= 5; v++ {
+			gs = append(gs, goldStandard{ID: fmt.Sprintf("%d.%d", c, v)})
+		}
+	}
+
+	tt := []struct {
+		split     experimentSplit
+		folds     int
+		testSizes []int
+	}{
+		{split: experimentSplit{Strategy: "head", Train: 0.4}, folds: 1, testSizes: []int{9}},
+		{split: experimentSplit{Strategy: "random", Train: 0.4}, folds: 1, testSizes: []int{9}},
+		{split: experimentSplit{Strategy: "kfold", Folds: 4}, folds: 4, testSizes: []int{3, 4, 4, 4}},
+		{split: experimentSplit{Strategy: "chant"}, folds: 3, testSizes: []int{5, 5, 5}},
+	}
+	for _, v := range tt {
+		folds, err := v.split.folds(gs, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(folds) != v.folds {
+			t.Fatal("Expected ", v.folds, " folds got ", len(folds))
+		}
+		tested := map[string]int{}
+		for i, f := range folds {
+			if len(f.test) != v.testSizes[i] || len(f.train)+len(f.test) != len(gs) {
+				t.Error("Expected ", v.testSizes[i], " test problems out of ", len(gs), " got ", len(f.test), " and ", len(f.train), " training problems")
+			}
+			inTest := map[string]bool{}
+			for _, g := range f.test {
+				inTest[g.ID] = true
+				tested[g.ID]++
+			}
+			for _, g := range f.train {
+				if inTest[g.ID] {
+					t.Error("Expected ", g.ID, " only in the test set of ", f.Name)
+				}
+			}
+		}
+		if v.folds > 1 && len(tested) != len(gs) {
+			t.Error("Expected every problem tested once got ", tested)
+		}
+	}
+
+	folds, _ := experimentSplit{Strategy: "chant"}.folds(gs, 1)
+	for _, g := range folds[2].test {
+		if !strings.HasPrefix(g.ID, "3.") {
+			t.Error("Expected only chant 3 in ", folds[2].Name, " got ", g.ID)
+		}
+	}
+	if _, err := (experimentSplit{Strategy: "kfold", Folds: 20}).folds(gs, 1); err == nil {
+		t.Error("Expected error with more folds than problems")
+	}
+	if _, err := (experimentSplit{Strategy: "head", Train: 0.01}).folds(gs, 1); err == nil {
+		t.Error("Expected error with an empty training set")
+	}
+}
+
+func TestAggregateMetrics(t *testing.T) {
+	hom := func(id string) aligner.Word { return aligner.Word{ID: "HOM." + id, Source: "HOM"} }
+	para := func(id string) aligner.Word { return aligner.Word{ID: "PARA." + id, Source: "PARA"} }
+	sub := func(from, to []aligner.Word) *aligner.Sub { return &aligner.Sub{From: from, To: to} }
+
+	// the gold Sub has 4 links, of which the predicted Sub finds 2
+	gold1 := aligner.NewFromEdits(
+		sub([]aligner.Word{hom("1"), hom("2")}, []aligner.Word{para("1"), para("2")}),
+		&aligner.Eq{From: hom("3"), To: para("3")},
+	)
+	predicted1 := aligner.NewFromEdits(
+		sub([]aligner.Word{hom("1")}, []aligner.Word{para("1"), para("2")}),
+		&aligner.Del{W: hom("2")},
+		sub([]aligner.Word{hom("3")}, []aligner.Word{para("3")}),
+	)
+	gold2 := aligner.NewFromEdits(&aligner.Eq{From: hom("4"), To: para("4")})
+	predicted2 := aligner.NewFromEdits(sub([]aligner.Word{hom("4")}, []aligner.Word{para("5")}), &aligner.Ins{W: para("4")})
+
+	results := []verseResult{
+		{ID: "1.1", EditAccuracy: 1, ScoreAccuracy: 0.25, Links: aligner.CompareLinks(gold1, predicted1)},
+		{ID: "1.2", EditAccuracy: 0.5, ScoreAccuracy: 0.75, Links: aligner.CompareLinks(gold2, predicted2)},
+	}
+	if c := results[0].Links; c != (aligner.LinkCounts{Predicted: 3, Gold: 5, Correct: 3}) {
+		t.Error("Expected 3 predicted, 5 gold and 3 correct links got ", c)
+	}
+
+	// 4 predicted, 6 gold and 3 correct links
+	expected := metrics{EditAccuracy: 0.75, ScoreAccuracy: 0.5, Precision: 0.75, Recall: 0.5, F1: 0.6, AER: 0.4}
+	m := aggregateMetrics(results)
+	got, want := m.fields(), expected.fields()
+	for i := range want {
+		if math.Abs(*got[i]-*want[i]) > 1e-9 {
+			t.Error("Expected ", expected, " got ", m)
+			break
+		}
+	}
+	if m := aggregateMetrics(nil); m != (metrics{}) {
+		t.Error("Expected zero metrics without results got ", m)
+	}
+}
+
+func TestMeanStd(t *testing.T) {
+	mean, std := meanStd([]metrics{{EditAccuracy: 0.5, ScoreAccuracy: 1}, {EditAccuracy: 0.7, ScoreAccuracy: 1}})
+	if math.Abs(mean.EditAccuracy-0.6) > 1e-9 || mean.ScoreAccuracy != 1 {
+		t.Error("Expected mean {0.6 1} got ", mean)
+	}
+	if math.Abs(std.EditAccuracy-math.Sqrt(0.02)) > 1e-9 || std.ScoreAccuracy != 0 {
+		t.Error("Expected std {", math.Sqrt(0.02), " 0} got ", std)
+	}
+	if _, std := meanStd([]metrics{{EditAccuracy: 0.5}}); std != (metrics{}) {
+		t.Error("Expected zero std for a single fold got ", std)
+	}
+}
+
+func TestVerseReport(t *testing.T) {
+	h := aligner.Word{ID: "HOM.1", Text: "thea"}
+	p := aligner.Word{ID: "PARA.1", Text: "thea"}
+	gold := aligner.NewFromEdits(&aligner.Eq{From: h, To: p})
+	good := verseResult{Alignment: gold, Gold: gold, HOMWords: 1, PARAWords: 1, EditAccuracy: 1, Links: aligner.CompareLinks(gold, gold)}
+	bad := verseResult{Alignment: aligner.NewFromEdits(&aligner.Del{W: h}, &aligner.Ins{W: p}), Gold: gold, HOMWords: 1, PARAWords: 1}
+	bad.Links = aligner.CompareLinks(gold, bad.Alignment)
+
+	results := []verseResult{}
+	for _, v := range []struct {
+		id string
+		r  verseResult
+	}{{"2.10", good}, {"1.2", bad}, {"2.9", bad}, {"1.10", good}} {
+		v.r.ID = v.id
+		results = append(results, v.r)
+	}
+
+	r := newVerseReport(results)
+	ids := []string{}
+	for _, v := range r.Verses {
+		ids = append(ids, v.ID)
+	}
+	if expected := []string{"1.2", "1.10", "2.9", "2.10"}; !reflect.DeepEqual(ids, expected) {
+		t.Error("Expected ", expected, " got ", ids)
+	}
+	if r.Verses[0].PredictedEdits != 2 || r.Verses[0].GoldEdits != 1 {
+		t.Error("Expected 2 predicted and 1 gold edits got ", r.Verses[0])
+	}
+	if len(r.Chants) != 2 || r.Chants[1].Chant != "2" || r.Chants[1].Verses != 2 || r.Chants[1].Metrics.F1 != 2.0/3 {
+		t.Error("Expected chant 2 with 2 verses and F1 2/3 got ", r.Chants)
+	}
+	if r.Worst[0].ID != "1.2" || r.Worst[1].ID != "2.9" || r.Worst[0].Metrics.F1 != 0 {
+		t.Error("Expected 1.2 and 2.9 as worst verses got ", r.Worst)
+	}
+
+	dir, err := ioutil.TempDir("", "report")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := r.save(func(name string) string { return filepath.Join(dir, name) }); err != nil {
+		t.Fatal(err)
+	}
+	d, err := ioutil.ReadFile(filepath.Join(dir, "worst.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(d)), "\n")
+	if len(lines) != 5 || !strings.HasPrefix(lines[1], "1,1.2,1,") {
+		t.Error("Expected header and 4 ranked verses got ", lines)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	words := aligner.DB{}
+	for i, text := range []string{"menin", "aeide", "thea"} {
+		w := aligner.Word{ID: fmt.Sprint("HOM.", i+1), Text: text, Source: "HOM", Chant: "1", Verse: "1"}
+		words[w.ID] = w
+	}
+	for i, text := range []string{"ten", "orgen", "thea"} {
+		w := aligner.Word{ID: fmt.Sprint("PARA.", i+1), Text: text, Source: "PARA", Chant: "1", Verse: "1"}
+		words[w.ID] = w
+	}
+	gold := aligner.NewFromEdits(
+		&aligner.Sub{From: []aligner.Word{words["HOM.1"]}, To: []aligner.Word{words["PARA.1"], words["PARA.2"]}},
+		&aligner.Del{W: words["HOM.2"]},
+		&aligner.Eq{From: words["HOM.3"], To: words["PARA.3"]},
+	)
+
+	dir, err := ioutil.TempDir("", "diff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "alignment.json")
+	if err := writeAlignments(path, map[string]*aligner.Alignment{"1.1": gold}); err != nil {
+		t.Fatal(err)
+	}
+	read, err := readAlignments(path, words)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if read["1.1"].EditsAccuracy(gold) != 1 || len(read["1.1"].Edits()) != 3 {
+		t.Error("Expected ", gold, " got ", read["1.1"])
+	}
+
+	predicted := aligner.NewFromEdits(
+		&aligner.Sub{From: []aligner.Word{words["HOM.1"], words["HOM.2"]}, To: []aligner.Word{words["PARA.2"]}},
+		&aligner.Ins{W: words["PARA.1"]},
+		&aligner.Eq{From: words["HOM.3"], To: words["PARA.3"]},
+	)
+	var sb strings.Builder
+	printDiff(&sb, "1.1", gold, predicted)
+	out := sb.String()
+	for _, expected := range []string{
+		"Verse 1.1: 2/6 words agree",
+		"sub → ten orgen",
+		"sub (+ aeide) → orgen",
+		"missed Del",
+		"=     HOM.3 thea",
+	} {
+		if !strings.Contains(out, expected) {
+			t.Error("Expected ", expected, " in ", out)
+		}
+	}
+}
+
+func TestReplayFeatureSets(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs a whole experiment")
+	}
+	dir, err := ioutil.TempDir("", "replay")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	cfg := defaultExperimentConfig()
+	cfg.Data.Voc, cfg.Data.Equiv, cfg.Data.Scholie = "", "", ""
+	cfg.Features = experimentFeatures{Sets: [][]string{{"TextualDistance", "TagDistance"}, {"TagDistance", "TextualDistance"}}}
+	cfg.Split = experimentSplit{Strategy: "random", Train: 0.05}
+	cfg.Learner.N, cfg.Learner.N0, cfg.Learner.Seed = 2, 1, 7
+	cfg.Output.Log = filepath.Join(dir, "test.log")
+	results := runExperiment(cfg)
+
+	// the second set must not depend on the order left by the training of the first one
+	m, err := loadManifest(resultPath(cfg.Output.Log, 2, "manifest.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := replay(m, 0, filepath.Join(dir, "replay.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(res, m.Result) {
+		t.Error("Expected ", m.Result, " got ", res)
+	}
+	if w1, w2 := results[0].Folds[0].Weights, results[1].Folds[0].Weights; w1[0] != w2[1] || w1[1] != w2[0] {
+		t.Error("Expected the same weights for the same features got ", w1, " and ", w2)
+	}
+}
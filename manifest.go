@@ -0,0 +1,155 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"path/filepath"
+	"reflect"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	aligner "github.com/szenzaro/iliad-aligner/aligner"
+)
+
+// manifestVersion is the version of the run manifest format
+const manifestVersion = 2
+
+// version is the code version recorded in the run manifests, it can be set with
+// go build -ldflags "-X main.version=$(git describe --always --dirty)"
+var version = ""
+
+// runManifest records everything needed to reproduce the result of a feature set
+type runManifest struct {
+	Version     int                      `json:"version"`
+	CodeVersion string                   `json:"codeVersion"`
+	Created     string                   `json:"created"`
+	Seed        int64                    `json:"seed"`
+	Inputs      map[string]aligner.Input `json:"inputs"`
+	Config      experimentConfig         `json:"config"` // configuration running only this feature set
+	Result      experimentResult         `json:"result"`
+}
+
+func newRunManifest(cfg experimentConfig, inputs map[string]aligner.Input, res experimentResult) runManifest {
+	cfg.Features = experimentFeatures{Sets: [][]string{res.Features}}
+	return runManifest{
+		Version:     manifestVersion,
+		CodeVersion: codeVersion(),
+		Created:     time.Now().UTC().Format(time.RFC3339),
+		Seed:        cfg.Learner.Seed,
+		Inputs:      inputs,
+		Config:      cfg,
+		Result:      res,
+	}
+}
+
+// resultPath returns the path of a file (manifest.json, errors.json, ...) about
+// the idx-th result of the log file
+func resultPath(logPath string, idx int, name string) string {
+	return fmt.Sprintf("%s-%d.%s", strings.TrimSuffix(logPath, filepath.Ext(logPath)), idx, name)
+}
+
+func (m runManifest) save(path string) error {
+	d, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, d, 0644)
+}
+
+func loadManifest(path string) (runManifest, error) {
+	m := runManifest{}
+	d, err := ioutil.ReadFile(path)
+	if err != nil {
+		return m, err
+	}
+	if err := json.Unmarshal(d, &m); err != nil {
+		return m, err
+	}
+	if m.Version != manifestVersion {
+		return m, fmt.Errorf("unsupported manifest version %d, expected %d", m.Version, manifestVersion)
+	}
+	return m, nil
+}
+
+// codeVersion returns the version set at build time or the VCS revision of the binary
+func codeVersion() string {
+	if version != "" {
+		return version
+	}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		rev, modified := "", false
+		for _, s := range info.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				rev = s.Value
+			case "vcs.modified":
+				modified = s.Value == "true"
+			}
+		}
+		if rev != "" {
+			if modified {
+				rev += "-dirty"
+			}
+			return rev
+		}
+	}
+	return "unknown"
+}
+
+func replayCmd(args []string) {
+	fs := flag.NewFlagSet("replay", flag.ExitOnError)
+	manifestFile := fs.String("manifest", "", "path to the manifest of the run to replay")
+	logPath := fs.String("log", "out/replay.log", "path to the log file of the replay")
+	workers := fs.Int("workers", 0, "number of verses aligned in parallel during the test, 0 uses all the CPUs")
+	fs.Parse(args)
+
+	if *manifestFile == "" {
+		log.Fatalln("missing -manifest")
+	}
+	m, err := loadManifest(*manifestFile)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	if v := codeVersion(); v != m.CodeVersion {
+		fmt.Println("Warning: the run was made with code version ", m.CodeVersion, ", replaying with ", v)
+	}
+
+	res, err := replay(m, *workers, *logPath)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	if reflect.DeepEqual(res, m.Result) {
+		fmt.Println("Replay matches the recorded result")
+		return
+	}
+	fmt.Println("Replay differs from the recorded result")
+	fmt.Println("recorded: ", m.Result)
+	fmt.Println("replayed: ", res)
+}
+
+// replay reruns the experiment of the manifest after checking that its inputs
+// did not change, writing the log to logPath
+func replay(m runManifest, workers int, logPath string) (experimentResult, error) {
+	cfg := m.Config
+	cfg.Workers = workers
+	cfg.Output = experimentOutput{Log: logPath}
+	inputs, err := cfg.Data.paths().inputs()
+	if err != nil {
+		return experimentResult{}, err
+	}
+	for k, in := range m.Inputs {
+		if inputs[k] != in {
+			return experimentResult{}, fmt.Errorf("input %s changed since the run: recorded %v, found %v", k, in, inputs[k])
+		}
+	}
+
+	results := runExperiment(cfg)
+	if len(results) != 1 {
+		return experimentResult{}, fmt.Errorf("expected one result, got %d", len(results))
+	}
+	return results[0], nil
+}
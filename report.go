@@ -0,0 +1,213 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	aligner "github.com/szenzaro/iliad-aligner/aligner"
+)
+
+// maxExamples is the number of example verses kept for each error category
+const maxExamples = 5
+
+// editTypes are the edit types in the rows and columns of the confusion matrix
+var editTypes = []string{"Ins", "Del", "Eq", "Sub", "none"}
+
+// errorReport compares the edit of each gold word with the predicted one
+type errorReport struct {
+	Confusion  map[string]map[string]int `json:"confusion"` // words by gold and predicted edit type
+	Categories map[string]*errorCategory `json:"categories"`
+}
+
+type errorCategory struct {
+	Words    int      `json:"words"`
+	Examples []string `json:"examples"` // IDs of the first verses with the error
+}
+
+func newErrorReport() *errorReport {
+	r := &errorReport{Confusion: map[string]map[string]int{}, Categories: map[string]*errorCategory{}}
+	for _, g := range editTypes {
+		r.Confusion[g] = map[string]int{}
+	}
+	return r
+}
+
+// add classifies the gold words of the verse id
+func (r *errorReport) add(id string, gold, predicted *aligner.Alignment) {
+	for _, d := range aligner.DiffWords(gold, predicted) {
+		if d.Gold == nil {
+			continue
+		}
+		r.Confusion[aligner.EditTypeName(d.Gold)][aligner.EditTypeName(d.Predicted)]++
+
+		name := d.Category()
+		c, ok := r.Categories[name]
+		if !ok {
+			c = &errorCategory{Examples: []string{}}
+			r.Categories[name] = c
+		}
+		c.Words++
+		if name != aligner.CategoryCorrect && len(c.Examples) < maxExamples && (len(c.Examples) == 0 || c.Examples[len(c.Examples)-1] != id) {
+			c.Examples = append(c.Examples, id)
+		}
+	}
+}
+
+func (r *errorReport) save(path string) error {
+	d, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, d, 0644)
+}
+
+// String returns the confusion matrix with the gold edit types as rows
+func (r *errorReport) String() string {
+	var sb strings.Builder
+	sb.WriteString("gold\\predicted")
+	for _, p := range editTypes {
+		sb.WriteString("\t" + p)
+	}
+	sb.WriteString("\n")
+	for _, g := range editTypes[:4] {
+		sb.WriteString(g)
+		for _, p := range editTypes {
+			sb.WriteString(fmt.Sprint("\t", r.Confusion[g][p]))
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// maxWorstVerses is the number of verses in the ranking of the worst aligned ones
+const maxWorstVerses = 50
+
+// verseRow is the evaluation of a verse in the verse report
+type verseRow struct {
+	ID             string  `json:"id"`
+	Chant          string  `json:"chant"`
+	HOMWords       int     `json:"homWords"`
+	PARAWords      int     `json:"paraWords"`
+	GoldEdits      int     `json:"goldEdits"`
+	PredictedEdits int     `json:"predictedEdits"`
+	Metrics        metrics `json:"metrics"`
+}
+
+// chantRow aggregates the evaluation of the verses of a chant
+type chantRow struct {
+	Chant   string  `json:"chant"`
+	Verses  int     `json:"verses"`
+	Metrics metrics `json:"metrics"`
+}
+
+// verseReport lists the evaluation of every verse, of every chant and the
+// ranking of the worst aligned verses
+type verseReport struct {
+	Verses []verseRow `json:"verses"` // in chant and verse order
+	Chants []chantRow `json:"chants"`
+	Worst  []verseRow `json:"worst"` // by increasing F1, then edit accuracy
+}
+
+func newVerseReport(results []verseResult) verseReport {
+	sorted := append([]verseResult{}, results...)
+	sort.Slice(sorted, func(i, j int) bool { return aligner.ProblemIDLess(sorted[i].ID, sorted[j].ID) })
+
+	r := verseReport{Verses: []verseRow{}, Chants: []chantRow{}}
+	byChant := map[string][]verseResult{}
+	for _, v := range sorted {
+		row := verseRow{
+			ID:             v.ID,
+			Chant:          strings.SplitN(v.ID, ".", 2)[0],
+			HOMWords:       v.HOMWords,
+			PARAWords:      v.PARAWords,
+			GoldEdits:      len(v.Gold.Edits()),
+			PredictedEdits: len(v.Alignment.Edits()),
+			Metrics:        v.metrics(),
+		}
+		if _, ok := byChant[row.Chant]; !ok {
+			r.Chants = append(r.Chants, chantRow{Chant: row.Chant})
+		}
+		byChant[row.Chant] = append(byChant[row.Chant], v)
+		r.Verses = append(r.Verses, row)
+	}
+	for i, c := range r.Chants {
+		r.Chants[i].Verses = len(byChant[c.Chant])
+		r.Chants[i].Metrics = aggregateMetrics(byChant[c.Chant])
+	}
+
+	r.Worst = append([]verseRow{}, r.Verses...)
+	sort.SliceStable(r.Worst, func(i, j int) bool {
+		x, y := r.Worst[i].Metrics, r.Worst[j].Metrics
+		if x.F1 != y.F1 {
+			return x.F1 < y.F1
+		}
+		return x.EditAccuracy < y.EditAccuracy
+	})
+	if len(r.Worst) > maxWorstVerses {
+		r.Worst = r.Worst[:maxWorstVerses]
+	}
+	return r
+}
+
+// save writes the report as verses.json and as the verses.csv, chants.csv and
+// worst.csv tables, path returns the path of each file
+func (r verseReport) save(path func(name string) string) error {
+	d, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(path("verses.json"), d, 0644); err != nil {
+		return err
+	}
+
+	verseHeader := append([]string{"ID", "Chant", "HOM Words", "PARA Words", "Gold Edits", "Predicted Edits"}, metricNames...)
+	verseRecord := func(v verseRow) []string {
+		return append([]string{v.ID, v.Chant, fmt.Sprint(v.HOMWords), fmt.Sprint(v.PARAWords), fmt.Sprint(v.GoldEdits), fmt.Sprint(v.PredictedEdits)}, metricRecord(v.Metrics)...)
+	}
+	verses := [][]string{verseHeader}
+	for _, v := range r.Verses {
+		verses = append(verses, verseRecord(v))
+	}
+	worst := [][]string{append([]string{"Rank"}, verseHeader...)}
+	for i, v := range r.Worst {
+		worst = append(worst, append([]string{fmt.Sprint(i + 1)}, verseRecord(v)...))
+	}
+	chants := [][]string{append([]string{"Chant", "Verses"}, metricNames...)}
+	for _, c := range r.Chants {
+		chants = append(chants, append([]string{c.Chant, fmt.Sprint(c.Verses)}, metricRecord(c.Metrics)...))
+	}
+
+	for name, records := range map[string][][]string{"verses.csv": verses, "chants.csv": chants, "worst.csv": worst} {
+		if err := writeCSV(path(name), records); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// metricRecord returns the metrics in the order of metricNames
+func metricRecord(m metrics) []string {
+	res := []string{}
+	for _, f := range m.fields() {
+		res = append(res, fmt.Sprint(*f))
+	}
+	return res
+}
+
+func writeCSV(path string, records [][]string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	w := csv.NewWriter(f)
+	if err := w.WriteAll(records); err != nil {
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	aligner "github.com/szenzaro/iliad-aligner/aligner"
+)
+
+// tmxDocument mirrors the subset of the TMX format produced by mkAlign.
+// The go-tmx types can not be used for writing since they lose the xml:lang namespace.
+type tmxDocument struct {
+	XMLName xml.Name  `xml:"tmx"`
+	Version string    `xml:"version,attr"`
+	Header  tmxHeader `xml:"header"`
+	Tu      []tmxTu   `xml:"body>tu"`
+}
+
+type tmxHeader struct {
+	Adminlang           string `xml:"adminlang,attr"`
+	Creationdate        string `xml:"creationdate,attr"`
+	Creationtool        string `xml:"creationtool,attr"`
+	Creationtoolversion string `xml:"creationtoolversion,attr"`
+	Datatype            string `xml:"datatype,attr"`
+	OTmf                string `xml:"o-tmf,attr"`
+	Segtype             string `xml:"segtype,attr"`
+	Srclang             string `xml:"srclang,attr"`
+}
+
+type tmxTu struct {
+	Tuv []tmxTuv `xml:"tuv"`
+}
+
+type tmxTuv struct {
+	Lang string `xml:"http://www.w3.org/XML/1998/namespace lang,attr"`
+	Seg  string `xml:"seg"`
+}
+
+// writeTMX saves the alignments in the TMX format used by the gold standard
+func writeTMX(path string, alignments map[string]*aligner.Alignment) error {
+	doc := tmxDocument{
+		Version: "1.4",
+		Header: tmxHeader{
+			Adminlang:           "en",
+			Creationdate:        time.Now().UTC().Format("20060102T150405Z"),
+			Creationtool:        "iliad-aligner",
+			Creationtoolversion: "1.0",
+			Datatype:            "xml",
+			OTmf:                "unknown",
+			Segtype:             "block",
+			Srclang:             "en",
+		},
+	}
+
+	ids := []string{}
+	for id := range alignments {
+		ids = append(ids, id)
+	}
+	aligner.SortProblemIDs(ids)
+	for _, id := range ids {
+		tus, err := alignmentToTus(alignments[id])
+		if err != nil {
+			return fmt.Errorf("verse %s: %v", id, err)
+		}
+		doc.Tu = append(doc.Tu, tus...)
+	}
+
+	d, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append([]byte(xml.Header), d...), 0644)
+}
+
+func alignmentToTus(a *aligner.Alignment) ([]tmxTu, error) {
+	tus := []tmxTu{}
+	for _, e := range a.Edits() {
+		var from, to []aligner.Word
+		switch e := e.(type) {
+		case *aligner.Ins:
+			to = []aligner.Word{e.W}
+		case *aligner.Del:
+			from = []aligner.Word{e.W}
+		case *aligner.Eq:
+			from, to = []aligner.Word{e.From}, []aligner.Word{e.To}
+			if !aligner.SameText(e.From, e.To) {
+				return nil, fmt.Errorf("Eq of %s and %s with different texts would be read as a Sub", e.From.ID, e.To.ID)
+			}
+		case *aligner.Sub:
+			from, to = e.From, e.To
+			if len(from) == 1 && len(to) == 1 && aligner.SameText(from[0], to[0]) {
+				return nil, fmt.Errorf("Sub of %s and %s with the same text would be read as an Eq", from[0].ID, to[0].ID)
+			}
+		}
+		fromSeg, err := wordsToSeg(from)
+		if err != nil {
+			return nil, err
+		}
+		toSeg, err := wordsToSeg(to)
+		if err != nil {
+			return nil, err
+		}
+		tus = append(tus, tmxTu{Tuv: []tmxTuv{
+			{Lang: "S_LTR", Seg: fromSeg},
+			{Lang: "C_LTR", Seg: toSeg},
+		}})
+	}
+	return tus, nil
+}
+
+// wordsToSeg formats the words as mkAlign tokens with their surface form, as in
+// the gold standard, e.g. Μῆνιν_(1_1)_{1-0}
+func wordsToSeg(ws []aligner.Word) (string, error) {
+	if len(ws) == 0 {
+		return "", nil
+	}
+	ids := make([]int, len(ws))
+	order := make([]int, len(ws))
+	for i, w := range ws {
+		id, err := tmxWordID(w)
+		if err != nil {
+			return "", err
+		}
+		ids[i], order[i] = id, i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return ids[order[i]] < ids[order[j]] })
+
+	var sb strings.Builder
+	sb.WriteString(" ")
+	for _, i := range order {
+		w := ws[i]
+		// the tokens are separated by spaces
+		form := w.Form
+		if form == "" || strings.ContainsAny(form, " \t\n") {
+			form = w.Text
+		}
+		sb.WriteString(fmt.Sprintf("%s_(%s_%s)_{%d-0} ", form, w.Chant, w.Verse, ids[i]))
+	}
+	return sb.String(), nil
+}
+
+// tmxWordID returns the number of the word in its source, e.g. 12 for HOM.12
+func tmxWordID(w aligner.Word) (int, error) {
+	id, err := strconv.Atoi(strings.TrimPrefix(w.ID, w.Source+"."))
+	if err != nil {
+		return 0, fmt.Errorf("word %q has no numeric ID in %s", w.ID, w.Source)
+	}
+	return id, nil
+}
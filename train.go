@@ -0,0 +1,96 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"math/rand"
+	"strings"
+
+	aligner "github.com/szenzaro/iliad-aligner/aligner"
+)
+
+const defaultFeatures = "TextualDistance,TagDistance,VocDistance,ScholieDistance,EqEquivTermDistance"
+
+func trainCmd(args []string) {
+	fs := flag.NewFlagSet("train", flag.ExitOnError)
+	paths := addDataFlags(fs)
+	featuresList := fs.String("features", defaultFeatures, "comma separated list of the features to learn")
+	modelPath := fs.String("o", "out/model.json", "path to the output model file")
+	split := fs.Float64("split", 0.3, "fraction of the gold standard used as training set")
+	n := fs.Int("N", 50, "number of training epochs")
+	n0 := fs.Int("N0", 10, "number of initial epochs excluded from the averaged weights")
+	r0 := fs.Float64("R0", 1.0, "initial learning rate")
+	r := fs.Float64("r", 0.8, "learning rate decay per epoch")
+	subseqLen := fs.Int("subseq", 1, "max length of the word subsequences in a substitution")
+	seed := fs.Int64("seed", 1, "seed of the random choices of the training")
+	alignerName := fs.String("aligner", "greek", "aligner used during the training: greek, hungarian, monotonic, itg or beam[:width]")
+	fs.Parse(args)
+
+	if *n0 >= *n {
+		log.Fatalln("N0 must be lower than N")
+	}
+	if *split <= 0 || *split > 1 {
+		log.Fatalln("split must be in (0, 1]")
+	}
+	ar, err := newAligner(*alignerName)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	names := parseFeatureNames(*featuresList)
+	params := aligner.LearnParams{N: *n, N0: *n0, R0: *r0, R: *r, SubseqLen: *subseqLen, Seed: *seed}
+	model := aligner.NewModel(names, make([]float64, len(names)), params)
+	ff, _, err := model.Resolve()
+	if err != nil {
+		log.Fatalln(err)
+	}
+	model.Inputs, err = paths.inputs()
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	ctx := loadAdditionalData(paths)
+	fmt.Println("Loading words database")
+	wordsDB, err := loadDB(*paths.words)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	ctx.LoadVerses(wordsDB)
+	fmt.Println("Loading gold standard")
+	gs := loadGoldStandard(*paths.goldStandard, wordsDB)
+	trainingSet := gs[:int(*split*float64(len(gs)))]
+	if aligner.NeedsResource(names, "LemmaAssociation") {
+		model.Association = lemmaAssociation(trainingSet)
+		ctx.Set("LemmaAssociation", model.Association)
+	}
+	if err := aligner.CheckResources(names, ctx); err != nil {
+		log.Fatalln(err)
+	}
+
+	alignAlg := func(p aligner.Problem, w []float64) *aligner.Alignment {
+		a, err := aligner.NewFromWordBags(p.From, p.To).Align(ar, ff, w, params.SubseqLen, ctx)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		return a
+	}
+
+	fmt.Println("- Start learning process on ", len(trainingSet), " problems with ", names)
+	model.Weights = learn(trainingSet, params.N, params.N0, params.R0, params.R, ff, alignAlg, ctx, rand.New(rand.NewSource(params.Seed)))
+	fmt.Println("- Learning done ", model.Weights)
+
+	if err := model.Save(*modelPath); err != nil {
+		log.Fatalln(err)
+	}
+	fmt.Println("Model saved to ", *modelPath)
+}
+
+func parseFeatureNames(s string) []string {
+	names := []string{}
+	for _, n := range strings.Split(s, ",") {
+		if n = strings.TrimSpace(n); n != "" {
+			names = append(names, n)
+		}
+	}
+	return names
+}